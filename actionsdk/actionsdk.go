@@ -1,10 +1,58 @@
 package actionsdk
 
 import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"io"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// ArgsFileEnv is the environment variable which, when set, contains the path to a
+	// file holding the JSON-encoded arguments.  This takes precedence over all other
+	// argument sources and is useful when payloads exceed argv length limits.
+	ArgsFileEnv = "INNGEST_ARGS_FILE"
+
+	// ArgsEnv is the environment variable which, when set, contains the JSON-encoded
+	// arguments.  This is used for runners which allow neither long argv entries nor
+	// stdin, and takes precedence over positional arguments and stdin.
+	ArgsEnv = "INNGEST_ARGS"
+
+	// ArgsIndexEnv is the environment variable which, when set, contains the index of
+	// the positional argument holding the arguments, for launchers which prepend their
+	// own arguments.  This defaults to 1, the first positional argument.
+	ArgsIndexEnv = "INNGEST_ARGS_INDEX"
+
+	// SupportedArgsVersion is the latest version of the args envelope that this SDK
+	// understands.  Payloads without a version are treated as legacy, unversioned args.
+	SupportedArgsVersion = 1
+
+	// DefaultMaxArgsSize is the default maximum size of the args, in bytes, after any
+	// decompression.
+	DefaultMaxArgsSize = 64 * 1024 * 1024
+
+	// previewLen is the number of bytes of the raw payload included in parse errors.
+	previewLen = 256
+)
+
+var (
+	// ErrNoArgs is returned when no arguments are provided to the step.
+	ErrNoArgs = errors.New("no arguments present")
+
+	// ErrNoEvent is returned when the arguments don't contain an event.
+	ErrNoEvent = errors.New("no event present")
+
+	// ErrArgsTooLarge is returned when the arguments exceed the maximum args size,
+	// either as read or once decompressed.
+	ErrArgsTooLarge = errors.New("arguments too large")
 )
 
 var (
@@ -14,134 +62,472 @@ var (
 	//
 	// If args is nil, args has not yet been initialized.
 	args *Args
+	// argsMu guards initialization of args, as GetArgs may be called from
+	// multiple goroutines concurrently.
+	argsMu sync.Mutex
+
+	// maxArgsSize is the maximum size of the args, in bytes.
+	maxArgsSize = DefaultMaxArgsSize
+
+	// argsIndex is the index within os.Args of the arguments, as set via SetArgsIndex,
+	// or zero if unset.
+	argsIndex int
+
+	// argsFromStdin records whether the args were read from stdin, in which case stdin
+	// is at EOF once the args are loaded.
+	argsFromStdin bool
+
+	// useNumber decodes JSON numbers within the args as json.Number when set.  This is
+	// atomic as it's read while decoding, which may happen concurrently with SetUseNumber.
+	useNumber atomic.Bool
+
+	// strictDecoding rejects unknown fields when decoding into caller types when set.
+	strictDecoding atomic.Bool
+
+	// lazySteps defers decoding Steps until first accessed when set.
+	lazySteps atomic.Bool
 )
 
 // Args is the function context, showing:
 // - The triggering event
 // - Data from previous steps
 // - Any function-specific config for this step.
+//
+// Steps is decoded along with the rest of the args unless lazy decoding is enabled via
+// SetLazySteps.
 type Args struct {
 	Event  Event                             `json:"event"`
 	Steps  map[string]map[string]interface{} `json:"steps"`
 	Ctx    map[string]interface{}            `json:"ctx"`
 	Config json.RawMessage                   `json:"config"`
+
+	// Events contains every event when the function is triggered by a batch of
+	// events.  For single events this may be empty, with the event only present
+	// within Event.
+	Events []Event `json:"events,omitempty"`
+
+	// StepNames contains the human-readable name of each step within the function,
+	// keyed by step ID.  This allows previous steps' output to be referenced by name
+	// via GetStepOutputByName, which is stable as the function's steps are edited.
+	StepNames map[string]string `json:"step_names,omitempty"`
+
+	// Version is the version of the args envelope.  Args returned by GetArgs are
+	// always normalized to SupportedArgsVersion, regardless of the version sent by
+	// the engine.
+	Version int `json:"version,omitempty"`
+
+	// rawSteps holds the undecoded Steps until they're first accessed, if enabled via
+	// SetLazySteps.
+	rawSteps json.RawMessage
+
+	// emitted holds events buffered via EmitEvent, which are written alongside the
+	// result.
+	emitted []Event
+}
+
+// SetLazySteps sets whether the output of previous steps is decoded lazily.  Step output
+// may be large, and many actions only need the event;  when enabled, the Steps field is
+// left nil when decoding args and step accessors such as StepOutput and HasStep decode
+// Steps on first use.  Actions which read the Steps field directly must call DecodeSteps
+// first.  By default Steps is decoded along with the rest of the args.
+//
+// This must be called prior to the args being loaded to affect GetArgs, though it's safe
+// to call concurrently with decoding.
+func SetLazySteps(enabled bool) {
+	lazySteps.Store(enabled)
 }
 
-// Event is the triggering event for this function.
-type Event struct {
-	Name      string                 `json:"name"`
-	Data      map[string]interface{} `json:"data"`
-	User      map[string]interface{} `json:"user,omitempty"`
-	ID        string                 `json:"id,omitempty"`
-	Timestamp int64                  `json:"ts,omitempty"`
-	Version   string                 `json:"v,omitempty"`
+// UnmarshalJSON decodes args, deferring decoding of Steps until first accessed if
+// enabled via SetLazySteps.
+func (a *Args) UnmarshalJSON(byt []byte) error {
+	// Use an alias to prevent recursing into this method.
+	type alias Args
+	raw := struct {
+		*alias
+		Steps json.RawMessage `json:"steps"`
+	}{alias: (*alias)(a)}
+	if err := decodeJSON(byt, &raw); err != nil {
+		return err
+	}
+	a.Steps = nil
+	a.rawSteps = nil
+	if !isEmptyJSON(raw.Steps) {
+		a.rawSteps = raw.Steps
+	}
+	if lazySteps.Load() {
+		return nil
+	}
+	return a.DecodeSteps()
 }
 
-// Result is the data returned from this step.
-type Result struct {
-	Body   interface{} `json:"body"`
-	Status int         `json:"status"`
+// MarshalJSON encodes args, including any Steps which are yet to be decoded.
+func (a Args) MarshalJSON() ([]byte, error) {
+	type alias Args
+	if a.Steps == nil && a.rawSteps != nil {
+		return json.Marshal(struct {
+			alias
+			Steps json.RawMessage `json:"steps"`
+		}{alias: alias(a), Steps: a.rawSteps})
+	}
+	return json.Marshal(alias(a))
 }
 
-// WriteError writes an error to stdout with a standard format.  The error is
-// added to a json object with an "error" key: {"error": err.Error()}.
-//
-// This does _not_ stop the action or workflow.
+// GetEvent returns the event which triggered the function.  If the function was
+// triggered by a batch of events, this returns the first event in the batch.  This
+// returns ErrNoEvent if the args don't contain an event.
+func GetEvent() (Event, error) {
+	args, err := GetArgs()
+	if err != nil {
+		return Event{}, err
+	}
+	return args.GetEvent()
+}
+
+// GetEvents returns every event which triggered the function.  Functions triggered
+// by a single event receive a slice containing only that event.  The returned slice is a
+// copy which may be modified without affecting the args, though each event's Data and
+// User maps are shared.  This returns ErrNoEvent if the args don't contain an event.
+func GetEvents() ([]Event, error) {
+	args, err := GetArgs()
+	if err != nil {
+		return nil, err
+	}
+	return args.GetEvents()
+}
+
+// GetEvent returns the event which triggered the function in the same manner as the
+// package-level GetEvent.
+func (a *Args) GetEvent() (Event, error) {
+	evts, err := a.GetEvents()
+	if err != nil {
+		return Event{}, err
+	}
+	return evts[0], nil
+}
+
+// GetEvents returns every event which triggered the function in the same manner as the
+// package-level GetEvents.
+func (a *Args) GetEvents() ([]Event, error) {
+	if a == nil {
+		return nil, ErrNoEvent
+	}
+	if len(a.Events) > 0 {
+		return append([]Event(nil), a.Events...), nil
+	}
+	if a.Event.Name == "" {
+		return nil, ErrNoEvent
+	}
+	return []Event{a.Event}, nil
+}
+
+// GetArgs returns the arguments provided to the step, returning an error
+// if invalid
+func GetArgs() (*Args, error) {
+	argsMu.Lock()
+	defer argsMu.Unlock()
+
+	if args != nil {
+		return args, nil
+	}
+
+	a, err := loadArgs()
+	if err != nil {
+		return nil, err
+	}
+	args = a
+	if debugEnabled() {
+		if err := dumpArgs(os.Stderr, args); err != nil {
+			logger.Warn("unable to dump args", "error", err)
+		}
+	}
+	return args, nil
+}
+
+// ResetArgs clears the cached arguments so that the next call to GetArgs parses them
+// again.  This is intended for tests which exercise multiple payloads within the same
+// process.
+func ResetArgs() {
+	argsMu.Lock()
+	defer argsMu.Unlock()
+	args = nil
+}
+
+// SetArgs replaces the cached arguments with the given args, so that GetArgs, GetConfig
+// and other accessors use them without parsing the process' input.  This is intended
+// for tests which construct args directly.
+func SetArgs(a *Args) {
+	argsMu.Lock()
+	defer argsMu.Unlock()
+	args = a
+}
+
+// loadArgs reads and parses the arguments from the first available source.
 //
-// To stop the action and prevent the workflow branch from continuing, exit
-// with a non-zero status code (ie. `os.Exit(1)`).
+// Sources are checked in the following order:
 //
-// To stop the action but allow workflows to continue, exit with a zero status
-// code (ie. `os.Exit(0)`)
-func WriteError(err error, retryable bool) {
-	// 4xx errors are not retryable;  it indicates that the request, or input
-	// data, is wrong and simply re-running this step will not fix.
-	status := 400
-	if retryable {
-		// 5xx errors are retryable
-		status = 500
-	}
-	byt, err := json.Marshal(map[string]interface{}{
-		"error":  err.Error(),
-		"status": status,
-	})
-	if err != nil {
-		log.Fatal(fmt.Errorf("unable to marshal error: %w", err))
+//  1. The file named by the ArgsFileEnv environment variable.
+//  2. The contents of the ArgsEnv environment variable.
+//  3. The first positional argument, or the argument set via SetArgsIndex.
+//  4. Stdin, if stdin is piped.
+func loadArgs() (*Args, error) {
+	// We pass in a JSON string as the first arugment, unless ArgsFileEnv or ArgsEnv is set.
+	// This payload contains the action metadata, workflow context, etc.
+	//
+	// Some orchestrators strip or truncate long argv entries, so if there's no positional
+	// argument we fall back to reading the payload from stdin.  This only happens when
+	// stdin is piped, so that interactive runs still fail with ErrNoArgs.
+	if path := os.Getenv(ArgsFileEnv); path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read arguments file %s: %w", path, err)
+		}
+		defer f.Close()
+
+		a, err := GetArgsFromReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("error loading arguments file %s: %w", path, err)
+		}
+		return a, nil
 	}
 
-	_, err = fmt.Println(string(byt))
+	if payload := os.Getenv(ArgsEnv); strings.TrimSpace(payload) != "" {
+		a, err := GetArgsFromReader(strings.NewReader(payload))
+		if err != nil {
+			return nil, fmt.Errorf("error loading arguments from %s: %w", ArgsEnv, err)
+		}
+		return a, nil
+	}
+
+	idx, err := argvIndex()
 	if err != nil {
-		log.Fatal(fmt.Errorf("unable to write error: %w", err))
+		return nil, err
+	}
+	switch {
+	case len(os.Args) > idx:
+		return GetArgsFromReader(strings.NewReader(os.Args[idx]))
+	case stdinPiped():
+		argsFromStdin = true
+		return GetArgsFromReader(os.Stdin)
+	default:
+		return nil, ErrNoArgs
 	}
 }
 
-// WriteResult writes the output as a JSON-encoded string to stdout.  Any data written
-// here is captured as action output, which is added to the workflow context and can be
-// used by future actions in the workflow.
+// SetArgsIndex sets the index within os.Args of the positional argument holding the
+// arguments, for launchers which prepend their own arguments.  This takes precedence
+// over ArgsIndexEnv.  If the index is beyond the provided arguments and stdin isn't
+// piped, GetArgs returns ErrNoArgs.  An index less than 1 restores the default of 1.
 //
-// Note that this does _not_ stop the action.  To stop the action, call `os.Exit(0)` or
-// return from your main function.
-func WriteResult(i *Result) error {
-	if i == nil {
-		_, err := fmt.Fprint(os.Stdout, `{"body": null, "status": 201}`)
-		return err
+// This must be called prior to the args being loaded, and isn't safe to call
+// concurrently with GetArgs.
+func SetArgsIndex(i int) {
+	if i < 1 {
+		i = 0
+	}
+	argsIndex = i
+}
+
+// argvIndex returns the index within os.Args of the arguments.
+func argvIndex() (int, error) {
+	if argsIndex > 0 {
+		return argsIndex, nil
+	}
+	env := os.Getenv(ArgsIndexEnv)
+	if env == "" {
+		return 1, nil
+	}
+	idx, err := strconv.Atoi(env)
+	if err != nil || idx < 1 {
+		return 0, fmt.Errorf("invalid %s: %q", ArgsIndexEnv, env)
+	}
+	return idx, nil
+}
+
+// GetArgsFromReader decodes the JSON-encoded arguments from the given reader.  Unlike
+// GetArgs, this never reads from or modifies the arguments cached for the current
+// process, which makes it useful for testing action logic with in-memory payloads.
+// Surrounding whitespace is ignored, and an empty payload returns ErrNoArgs.  Payloads
+// larger than the size set via SetMaxArgsSize return ErrArgsTooLarge.
+func GetArgsFromReader(r io.Reader) (*Args, error) {
+	byt, err := readLimited(r)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read arguments: %w", err)
+	}
+
+	if byt, err = decodePayload(byt); err != nil {
+		return nil, err
+	}
+	// Shells may pass an empty or blank argument, which isn't a malformed payload.
+	if byt = bytes.TrimSpace(byt); len(byt) == 0 {
+		return nil, ErrNoArgs
 	}
 
-	byt, err := json.Marshal(i)
+	envelope := struct {
+		Version int `json:"version"`
+	}{}
+	if err := json.Unmarshal(byt, &envelope); err != nil {
+		return nil, parseError(err, byt)
+	}
+	if envelope.Version < 0 || envelope.Version > SupportedArgsVersion {
+		logger.Warn("args version mismatch", "version", envelope.Version, "supported", SupportedArgsVersion)
+		return nil, fmt.Errorf("unsupported args version %d, expected <= %d", envelope.Version, SupportedArgsVersion)
+	}
+
+	a, err := migrateArgs(envelope.Version, byt)
 	if err != nil {
-		return fmt.Errorf("error writing output: %w", err)
+		return nil, parseError(err, byt)
 	}
+	return a, nil
+}
 
-	_, err = fmt.Println(string(byt))
-	return err
+// SetMaxArgsSize sets the maximum size of the args, in bytes, which defaults to
+// DefaultMaxArgsSize.  The limit applies both to the payload as read and to the payload
+// once decompressed, so that small compressed payloads can't exhaust memory.  A size of
+// zero or less disables the limit.
+//
+// This is not safe to call concurrently with loading args or serving requests.
+func SetMaxArgsSize(n int) {
+	maxArgsSize = n
 }
 
-// GetConfig returns the config for the action as configured within this specific workflow.
-// The type for this struct must match the definitions within the action config (action.cue).
-func GetConfig(dest interface{}) error {
-	args, err := GetArgs()
+// readLimited reads all of r, returning ErrArgsTooLarge if r contains more than the
+// maximum args size.
+func readLimited(r io.Reader) ([]byte, error) {
+	max := maxArgsSize
+	if max <= 0 {
+		return io.ReadAll(r)
+	}
+	byt, err := io.ReadAll(io.LimitReader(r, int64(max)+1))
 	if err != nil {
+		return nil, err
+	}
+	if len(byt) > max {
+		return nil, fmt.Errorf("%w: exceeds the maximum of %d bytes", ErrArgsTooLarge, max)
+	}
+	return byt, nil
+}
+
+// SetUseNumber sets whether numbers within the args, such as within Event.Data, are
+// decoded as json.Number instead of float64.  Integers beyond 2^53, eg. 64-bit IDs, lose
+// precision when decoded as float64;  json.Number preserves the number as written:
+//
+//	actionsdk.SetUseNumber(true)
+//	id, err := evt.Data["id"].(json.Number).Int64()
+//
+// This must be called prior to the args being loaded to affect GetArgs, though it's safe
+// to call concurrently with decoding.
+func SetUseNumber(enabled bool) {
+	useNumber.Store(enabled)
+}
+
+// decodeJSON decodes the JSON document into v, decoding numbers as json.Number if
+// enabled via SetUseNumber.
+func decodeJSON(byt []byte, v interface{}) error {
+	if !useNumber.Load() {
+		return json.Unmarshal(byt, v)
+	}
+	dec := json.NewDecoder(bytes.NewReader(byt))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
 		return err
 	}
-	return json.Unmarshal(args.Config, dest)
+	if _, err := dec.Token(); err != io.EOF {
+		return fmt.Errorf("invalid data after top-level value")
+	}
+	return nil
+}
+
+// SetStrictDecoding sets whether decoding config, event data and step output into the
+// caller's types rejects fields which aren't present in the destination type.  By
+// default unknown fields are ignored;  in strict mode GetConfig, DataInto, EventData,
+// StepOutput and similar helpers return an error naming the unexpected field, which
+// catches drift between the action's types and the payload during testing.
+//
+// This is safe to call concurrently with decoding.
+func SetStrictDecoding(enabled bool) {
+	strictDecoding.Store(enabled)
 }
 
-// GetSecret returns the secret stored within the current workspace.  If no secret is found
-// this returns an error.
-func GetSecret(str string) (string, error) {
-	if secret := os.Getenv(str); secret != "" {
-		return secret, nil
+// unmarshalInto decodes the JSON document into the caller's dest, rejecting unknown
+// fields if enabled via SetStrictDecoding.
+func unmarshalInto(byt []byte, dest interface{}) error {
+	if !strictDecoding.Load() {
+		return json.Unmarshal(byt, dest)
 	}
-	return "", fmt.Errorf("secret not found: %s", str)
+	dec := json.NewDecoder(bytes.NewReader(byt))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dest); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return fmt.Errorf("invalid data after top-level value")
+	}
+	return nil
 }
 
-// GetArgs returns the arguments provided to the step, returning an error
-// if invalid
-func GetArgs() (*Args, error) {
-	if args != nil {
-		return args, nil
+// parseError wraps an error encountered when parsing args with a preview of the payload
+// received, truncated to previewLen bytes so that large payloads don't flood logs.
+func parseError(err error, byt []byte) error {
+	preview := string(byt)
+	if len(preview) > previewLen {
+		preview = preview[:previewLen] + "..."
 	}
 
-	// We pass in a JSON string as the first arugment.  This payload contains the action metadata,
-	// workflow context, etc.
-	if len(os.Args) < 2 {
-		return nil, fmt.Errorf("no arguments present")
+	var serr *json.SyntaxError
+	if errors.As(err, &serr) {
+		return fmt.Errorf("unable to parse arguments at offset %d: %w (received %q)", serr.Offset, err, preview)
 	}
+	return fmt.Errorf("unable to parse arguments: %w (received %q)", err, preview)
+}
 
-	args = &Args{}
-	if err := json.Unmarshal([]byte(os.Args[1]), args); err != nil {
-		return nil, fmt.Errorf("unable to parse arguments: %s", err)
+// decodePayload unwraps any transport encoding applied to the arguments.  Payloads
+// passed through shells or environment variables may be base64 encoded to avoid
+// quoting issues;  these never start with "{" and must decode cleanly.  Large
+// payloads may also be gzip compressed, optionally within the base64 encoding.
+func decodePayload(byt []byte) ([]byte, error) {
+	if !isGzip(byt) {
+		byt = bytes.TrimSpace(byt)
+		if len(byt) == 0 || byt[0] == '{' || json.Valid(byt) {
+			return byt, nil
+		}
+		if decoded, err := base64.StdEncoding.DecodeString(string(byt)); err == nil {
+			byt = decoded
+		}
 	}
 
-	return args, nil
+	if !isGzip(byt) {
+		return byt, nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(byt))
+	if err != nil {
+		return nil, fmt.Errorf("unable to decompress arguments: %w", err)
+	}
+	defer zr.Close()
+	if byt, err = readLimited(zr); err != nil {
+		return nil, fmt.Errorf("unable to decompress arguments: %w", err)
+	}
+	return byt, nil
+}
+
+// isGzip returns whether the given bytes start with the gzip magic number.
+func isGzip(byt []byte) bool {
+	return len(byt) >= 2 && byt[0] == 0x1f && byt[1] == 0x8b
+}
+
+// stdinPiped returns whether stdin is a pipe or file rather than a terminal.
+func stdinPiped() bool {
+	fi, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice == 0
 }
 
 // MustGetArgs returns the arguments provided to the step.
 func MustGetArgs() *Args {
 	args, err := GetArgs()
 	if err != nil {
-		WriteError(err, false)
-		os.Exit(1)
+		StopAndFail(err)
 	}
 	return args
 }
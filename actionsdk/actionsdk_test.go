@@ -0,0 +1,303 @@
+package actionsdk
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// largeStepsPayload returns args containing the output of n steps, each holding a
+// sizeable object, as sent for functions late in a long run.
+func largeStepsPayload(tb testing.TB, n int) []byte {
+	tb.Helper()
+	steps := map[string]map[string]interface{}{}
+	for i := 0; i < n; i++ {
+		rows := make([]map[string]interface{}, 50)
+		for r := range rows {
+			rows[r] = map[string]interface{}{"id": r, "name": strings.Repeat("x", 32), "ok": true}
+		}
+		steps[fmt.Sprintf("step-%d", i)] = map[string]interface{}{"rows": rows}
+	}
+	byt, err := json.Marshal(map[string]interface{}{
+		"event": map[string]interface{}{"name": "order/placed", "data": map[string]interface{}{"id": 1}},
+		"steps": steps,
+	})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return byt
+}
+
+func TestStepsDecodedEagerly(t *testing.T) {
+	a, err := GetArgsFromReader(strings.NewReader(`{"steps":{"1":{"a":1}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a.Steps) != 1 || a.Steps["1"]["a"] != float64(1) {
+		t.Fatalf("unexpected steps: %#v", a.Steps)
+	}
+}
+
+func TestLazySteps(t *testing.T) {
+	SetLazySteps(true)
+	defer SetLazySteps(false)
+
+	a, err := GetArgsFromReader(strings.NewReader(`{"steps":{"1":{"a":1}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Steps != nil {
+		t.Fatalf("expected steps to be decoded lazily, got %#v", a.Steps)
+	}
+	ok, err := a.HasStep("1")
+	if err != nil || !ok {
+		t.Fatalf("expected step to be present, got %v, %v", ok, err)
+	}
+	if len(a.Steps) != 1 {
+		t.Fatalf("expected steps to be decoded once accessed, got %#v", a.Steps)
+	}
+}
+
+func BenchmarkGetArgsFromReader(b *testing.B) {
+	payload := largeStepsPayload(b, 200)
+	for _, lazy := range []bool{false, true} {
+		b.Run(fmt.Sprintf("lazy=%v", lazy), func(b *testing.B) {
+			SetLazySteps(lazy)
+			defer SetLazySteps(false)
+
+			b.SetBytes(int64(len(payload)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				a, err := GetArgsFromReader(bytes.NewReader(payload))
+				if err != nil {
+					b.Fatal(err)
+				}
+				if _, err := a.GetEvent(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestGetArgsFromReaderBase64(t *testing.T) {
+	payload := `{"event":{"name":"order/placed","data":{"id":1}},"config":{"limit":5}}`
+	encoded := base64.StdEncoding.EncodeToString([]byte(payload))
+
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{name: "plain", input: payload},
+		{name: "base64", input: encoded},
+		{name: "base64 with surrounding whitespace", input: "\n " + encoded + "\n"},
+		{name: "invalid base64", input: "not-json-or-base64!", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := GetArgsFromReader(strings.NewReader(tt.input))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if a.Event.Name != "order/placed" || a.Event.Data["id"] != float64(1) {
+				t.Fatalf("unexpected event: %#v", a.Event)
+			}
+			if string(a.Config) != `{"limit":5}` {
+				t.Fatalf("unexpected config: %s", a.Config)
+			}
+		})
+	}
+}
+
+// gzipBytes returns byt gzip compressed.
+func gzipBytes(tb testing.TB, byt []byte) []byte {
+	tb.Helper()
+	buf := &bytes.Buffer{}
+	zw := gzip.NewWriter(buf)
+	if _, err := zw.Write(byt); err != nil {
+		tb.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		tb.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestGetArgsFromReaderGzip(t *testing.T) {
+	// A tiny payload compresses to more bytes than its plaintext, which must still
+	// be detected as gzip rather than decoded as JSON.
+	small := []byte(`{"event":{"name":"a"}}`)
+	large := largeStepsPayload(t, 20)
+
+	tests := []struct {
+		name     string
+		input    []byte
+		wantName string
+	}{
+		{name: "compressed larger than plaintext", input: gzipBytes(t, small), wantName: "a"},
+		{name: "large", input: gzipBytes(t, large), wantName: "order/placed"},
+		{name: "base64 gzip", input: []byte(base64.StdEncoding.EncodeToString(gzipBytes(t, small))), wantName: "a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := GetArgsFromReader(bytes.NewReader(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if a.Event.Name != tt.wantName {
+				t.Fatalf("expected event %q, got %q", tt.wantName, a.Event.Name)
+			}
+		})
+	}
+	if len(gzipBytes(t, small)) <= len(small) {
+		t.Fatal("expected the small payload to grow when compressed")
+	}
+}
+
+func TestGetArgsFromReaderGzipLimit(t *testing.T) {
+	SetMaxArgsSize(1024)
+	defer SetMaxArgsSize(DefaultMaxArgsSize)
+
+	// The compressed payload is within the limit, but decompresses beyond it.
+	payload := gzipBytes(t, largeStepsPayload(t, 5))
+	if len(payload) > 1024 {
+		t.Fatalf("expected the compressed payload to be within the limit, got %d bytes", len(payload))
+	}
+	if _, err := GetArgsFromReader(bytes.NewReader(payload)); !errors.Is(err, ErrArgsTooLarge) {
+		t.Fatalf("expected ErrArgsTooLarge, got %v", err)
+	}
+}
+
+func TestGetArgsConcurrent(t *testing.T) {
+	t.Setenv(ArgsEnv, `{"event":{"name":"order/placed","data":{"id":1}}}`)
+	ResetArgs()
+	defer ResetArgs()
+	defer SetUseNumber(false)
+	defer SetStrictDecoding(false)
+
+	const n = 16
+	results := make([]*Args, n)
+	errs := make([]error, n)
+	wg := sync.WaitGroup{}
+	for i := 0; i < n; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = GetArgs()
+		}(i)
+		// Decoding options may be toggled while args are loading.
+		go func(i int) {
+			defer wg.Done()
+			SetUseNumber(i%2 == 0)
+			SetStrictDecoding(i%2 == 0)
+			SetLazySteps(false)
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < n; i++ {
+		if errs[i] != nil {
+			t.Fatalf("unexpected error: %v", errs[i])
+		}
+		if results[i] != results[0] {
+			t.Fatal("expected every caller to receive the same args")
+		}
+	}
+	if results[0].Event.Name != "order/placed" {
+		t.Fatalf("unexpected event: %#v", results[0].Event)
+	}
+}
+
+func TestEmptyArgsAccessors(t *testing.T) {
+	tests := []struct {
+		name string
+		args *Args
+	}{
+		{name: "nil", args: nil},
+		{name: "zero value", args: &Args{}},
+		{name: "empty steps", args: &Args{Steps: map[string]map[string]interface{}{}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := tt.args
+			if _, err := a.GetEvent(); !errors.Is(err, ErrNoEvent) {
+				t.Fatalf("expected ErrNoEvent from GetEvent, got %v", err)
+			}
+			if _, err := a.GetEvents(); !errors.Is(err, ErrNoEvent) {
+				t.Fatalf("expected ErrNoEvent from GetEvents, got %v", err)
+			}
+
+			var dest map[string]interface{}
+			for name, err := range map[string]error{
+				"GetStepOutput":       a.GetStepOutput("1", &dest),
+				"GetStepOutputInto":   a.GetStepOutputInto("1", "key", &dest),
+				"GetStepOutputByName": a.GetStepOutputByName("fetch", &dest),
+			} {
+				if !errors.Is(err, ErrStepNotFound) {
+					t.Fatalf("expected ErrStepNotFound from %s, got %v", name, err)
+				}
+			}
+			if _, err := a.GetStepOutputRaw("1"); !errors.Is(err, ErrStepNotFound) {
+				t.Fatalf("expected ErrStepNotFound from GetStepOutputRaw, got %v", err)
+			}
+			if _, err := StepOutputFrom[map[string]interface{}](a, "1"); !errors.Is(err, ErrStepNotFound) {
+				t.Fatalf("expected ErrStepNotFound from StepOutputFrom, got %v", err)
+			}
+
+			if ok, err := a.HasStep("1"); ok || err != nil {
+				t.Fatalf("expected no step from HasStep, got %v, %v", ok, err)
+			}
+			if ids, err := a.ListStepIDs(); len(ids) != 0 || err != nil {
+				t.Fatalf("expected no step IDs, got %v, %v", ids, err)
+			}
+			if outputs, err := a.AllStepOutputs(); len(outputs) != 0 || err != nil {
+				t.Fatalf("expected no step outputs, got %v, %v", outputs, err)
+			}
+		})
+	}
+}
+
+func TestGetEvents(t *testing.T) {
+	tests := []struct {
+		name string
+		args *Args
+		want []string
+	}{
+		{name: "single event", args: &Args{Event: Event{Name: "a"}}, want: []string{"a"}},
+		{name: "batch", args: &Args{Event: Event{Name: "a"}, Events: []Event{{Name: "a"}, {Name: "b"}}}, want: []string{"a", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			evts, err := tt.args.GetEvents()
+			if err != nil {
+				t.Fatal(err)
+			}
+			names := []string{}
+			for _, e := range evts {
+				names = append(names, e.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.want, ",") {
+				t.Fatalf("expected events %q, got %q", tt.want, names)
+			}
+
+			// Modifying the returned events mustn't affect the args.
+			evts[0].Name = "modified"
+			if evt, _ := tt.args.GetEvent(); evt.Name != "a" {
+				t.Fatalf("expected the args to be unchanged, got %q", evt.Name)
+			}
+		})
+	}
+}
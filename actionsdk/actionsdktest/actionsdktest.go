@@ -0,0 +1,117 @@
+// Package actionsdktest provides utilities for testing actions built with actionsdk.
+package actionsdktest
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/inngest/inngestgo/actionsdk"
+)
+
+// ArgsBuilder builds args for use within tests, eg. via actionsdk.SetArgs:
+//
+//	actionsdk.SetArgs(actionsdktest.NewArgs().
+//		WithEvent(actionsdk.Event{Name: "order/placed"}).
+//		WithConfig(map[string]interface{}{"currency": "usd"}).
+//		WithStepOutput("fetch-user", map[string]interface{}{"id": 1}).
+//		Build())
+//
+// As builders are intended for tests, Build panics if any value can't be marshalled.
+type ArgsBuilder struct {
+	args *actionsdk.Args
+	err  error
+}
+
+// NewArgs returns a new ArgsBuilder for args containing no event, config or step output.
+func NewArgs() *ArgsBuilder {
+	return &ArgsBuilder{
+		args: &actionsdk.Args{
+			Steps:   map[string]map[string]interface{}{},
+			Ctx:     map[string]interface{}{},
+			Version: actionsdk.SupportedArgsVersion,
+		},
+	}
+}
+
+// WithEvent sets the event which triggered the function.
+func (b *ArgsBuilder) WithEvent(e actionsdk.Event) *ArgsBuilder {
+	b.args.Event = e
+	return b
+}
+
+// WithEvents sets the batch of events which triggered the function.  The first event
+// is also set as the args' event.
+func (b *ArgsBuilder) WithEvents(evts ...actionsdk.Event) *ArgsBuilder {
+	b.args.Events = evts
+	if len(evts) > 0 {
+		b.args.Event = evts[0]
+	}
+	return b
+}
+
+// WithConfig sets the step's config to the JSON encoding of v.
+func (b *ArgsBuilder) WithConfig(v interface{}) *ArgsBuilder {
+	byt, err := json.Marshal(v)
+	if err != nil {
+		b.fail(fmt.Errorf("error marshalling config: %w", err))
+		return b
+	}
+	b.args.Config = byt
+	return b
+}
+
+// WithStepOutput sets the output of the previous step with the given ID.  The output
+// must marshal to a JSON object.
+func (b *ArgsBuilder) WithStepOutput(id string, output interface{}) *ArgsBuilder {
+	byt, err := json.Marshal(output)
+	if err != nil {
+		b.fail(fmt.Errorf("error marshalling output of step %s: %w", id, err))
+		return b
+	}
+	out := map[string]interface{}{}
+	if err := json.Unmarshal(byt, &out); err != nil {
+		b.fail(fmt.Errorf("output of step %s is not an object: %w", id, err))
+		return b
+	}
+	b.args.Steps[id] = out
+	return b
+}
+
+// WithStepName sets the name of the step with the given ID.
+func (b *ArgsBuilder) WithStepName(id, name string) *ArgsBuilder {
+	if b.args.StepNames == nil {
+		b.args.StepNames = map[string]string{}
+	}
+	b.args.StepNames[id] = name
+	return b
+}
+
+// WithCtx sets the given key within the args' context.
+func (b *ArgsBuilder) WithCtx(key string, value interface{}) *ArgsBuilder {
+	b.args.Ctx[key] = value
+	return b
+}
+
+// Build returns the built args, panicking if any value couldn't be marshalled.
+func (b *ArgsBuilder) Build() *actionsdk.Args {
+	if b.err != nil {
+		panic(b.err)
+	}
+	return b.args
+}
+
+// JSON returns the JSON encoding of the built args, suitable for passing to
+// actionsdk.GetArgsFromReader.
+func (b *ArgsBuilder) JSON() []byte {
+	byt, err := json.Marshal(b.Build())
+	if err != nil {
+		panic(err)
+	}
+	return byt
+}
+
+func (b *ArgsBuilder) fail(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
@@ -0,0 +1,57 @@
+package actionsdktest
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sync"
+)
+
+// captureMu serializes captures, as each capture replaces the process' stdout.
+var captureMu sync.Mutex
+
+// CaptureOutput calls fn, returning everything written to stdout during the call.  This
+// allows the output of actionsdk.WriteResult and actionsdk.WriteError to be asserted
+// on within tests.  Stdout is restored once fn returns or panics.
+func CaptureOutput(fn func()) (string, error) {
+	captureMu.Lock()
+	defer captureMu.Unlock()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return "", err
+	}
+
+	// Read concurrently so that large writes don't block on a full pipe.
+	buf := &bytes.Buffer{}
+	done := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(buf, r)
+		done <- err
+	}()
+
+	stdout := os.Stdout
+	os.Stdout = w
+	restored := false
+	defer func() {
+		if restored {
+			return
+		}
+		// fn panicked;  release the pipe and reader before the panic continues.
+		os.Stdout = stdout
+		_ = w.Close()
+		<-done
+		_ = r.Close()
+	}()
+	fn()
+	os.Stdout = stdout
+	restored = true
+
+	werr := w.Close()
+	err = <-done
+	_ = r.Close()
+	if werr != nil {
+		return "", werr
+	}
+	return buf.String(), err
+}
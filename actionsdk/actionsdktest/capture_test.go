@@ -0,0 +1,80 @@
+package actionsdktest
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/inngest/inngestgo/actionsdk"
+)
+
+func TestCaptureOutput(t *testing.T) {
+	defer actionsdk.ResetResultState()
+
+	tests := []struct {
+		name string
+		fn   func()
+		want string
+	}{
+		{
+			name: "result",
+			fn: func() {
+				_ = actionsdk.WriteResult(&actionsdk.Result{Body: "ok", Status: 200})
+			},
+			want: `{"body":"ok","status":200}` + "\n",
+		},
+		{
+			name: "large output",
+			fn: func() {
+				fmt.Print(strings.Repeat("x", 1<<20))
+			},
+			want: strings.Repeat("x", 1<<20),
+		},
+		{
+			name: "nothing written",
+			fn:   func() {},
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actionsdk.ResetResultState()
+			got, err := CaptureOutput(tt.fn)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("expected %q, got %q", truncate(tt.want), truncate(got))
+			}
+		})
+	}
+}
+
+func TestCaptureOutputPanic(t *testing.T) {
+	stdout := os.Stdout
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Fatalf("expected the panic to propagate, got %v", r)
+			}
+		}()
+		_, _ = CaptureOutput(func() { panic("boom") })
+	}()
+	if os.Stdout != stdout {
+		t.Fatal("expected stdout to be restored")
+	}
+
+	// Captures must not deadlock on the lock held by the panicking capture.
+	got, err := CaptureOutput(func() { fmt.Print("ok") })
+	if err != nil || got != "ok" {
+		t.Fatalf("unexpected capture %q, %v", got, err)
+	}
+}
+
+func truncate(s string) string {
+	if len(s) > 64 {
+		return s[:64] + "..."
+	}
+	return s
+}
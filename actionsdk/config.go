@@ -0,0 +1,725 @@
+package actionsdk
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrConfigMissing is returned when decoding config which isn't present.
+	ErrConfigMissing = errors.New("config not present")
+
+	// ErrDuplicateConfigKey is returned when the config contains the same key more than
+	// once within an object, if enabled via SetRejectDuplicateConfigKeys.
+	ErrDuplicateConfigKey = errors.New("duplicate config key")
+)
+
+var (
+	// configEnvPrefix is the prefix of environment variables which override config
+	// values, or an empty string if config can't be overridden.
+	configEnvPrefix string
+
+	// rejectDuplicateKeys rejects config containing duplicate keys when set.
+	rejectDuplicateKeys atomic.Bool
+)
+
+// SetRejectDuplicateConfigKeys sets whether config containing the same key more than once
+// within an object is rejected.  encoding/json silently keeps the last value for each
+// key, which can mask bugs in tools which generate config;  when enabled GetConfig,
+// LoadConfig and similar functions return ErrDuplicateConfigKey naming the duplicated
+// key instead.  This is independent of SetStrictDecoding.
+//
+// This is safe to call concurrently with reading config.
+func SetRejectDuplicateConfigKeys(enabled bool) {
+	rejectDuplicateKeys.Store(enabled)
+}
+
+// GetConfig returns the config for the action as configured within this specific workflow.
+// The type for this struct must match the definitions within the action config (action.cue).
+// If no config is present this returns ErrConfigMissing.
+//
+// If an environment prefix is set via SetConfigEnvPrefix, environment variables override
+// the matching config values.
+func GetConfig(dest interface{}) error {
+	args, err := GetArgs()
+	if err != nil {
+		return err
+	}
+	return args.GetConfig(dest)
+}
+
+// GetConfig decodes the config for the action into dest in the same manner as the
+// package-level GetConfig.
+func (a *Args) GetConfig(dest interface{}) error {
+	if a == nil {
+		return ErrConfigMissing
+	}
+	if err := checkConfigKeys(a.Config); err != nil {
+		return err
+	}
+	config := a.Config
+	var err error
+	if configEnvPrefix != "" {
+		if config, err = overlayConfigEnv(config, dest); err != nil {
+			return err
+		}
+	}
+	if len(bytes.TrimSpace(config)) == 0 {
+		return ErrConfigMissing
+	}
+	return unmarshalInto(config, dest)
+}
+
+// SetConfigEnvPrefix allows environment variables to override config values within
+// GetConfig, which is useful when developing locally.  Each top-level config key may be
+// overridden by an environment variable named with the prefix followed by the key in
+// upper case:  with the prefix "ACTION_", "ACTION_API_URL" overrides the "api_url" key.
+//
+// Values are converted to the type of the destination field, or the type of the value
+// within the config if the destination isn't a struct.  GetConfig returns an error if a
+// value can't be converted, eg. "ACTION_RETRIES=many" for an int field.  An empty prefix
+// disables overrides, which is the default.
+//
+// This is not safe to call concurrently with reading config.
+func SetConfigEnvPrefix(prefix string) {
+	configEnvPrefix = prefix
+}
+
+// overlayConfigEnv returns the config with any values overridden via environment
+// variables applied.
+func overlayConfigEnv(config json.RawMessage, dest interface{}) (json.RawMessage, error) {
+	fields := map[string]json.RawMessage{}
+	if !isEmptyJSON(config) {
+		if err := json.Unmarshal(config, &fields); err != nil {
+			// Only objects can be overridden.
+			return config, nil
+		}
+	}
+
+	// Determine the kind of each overridable key, preferring the destination's field
+	// types over the types of the config's values.
+	kinds := map[string]reflect.Kind{}
+	for key, raw := range fields {
+		kinds[key] = jsonKind(raw)
+	}
+	for key, kind := range structFieldKinds(dest) {
+		kinds[key] = kind
+	}
+
+	overridden := false
+	for key, kind := range kinds {
+		env := configEnvPrefix + strings.ToUpper(key)
+		val, ok := os.LookupEnv(env)
+		if !ok {
+			continue
+		}
+		raw, err := coerceEnv(val, kind)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %w", env, err)
+		}
+		fields[key] = raw
+		overridden = true
+	}
+	if !overridden {
+		return config, nil
+	}
+	return json.Marshal(fields)
+}
+
+// structFieldKinds returns the kind of each field of the struct pointed to by v, keyed
+// by the field's JSON name.  This returns nil if v doesn't point to a struct.
+func structFieldKinds(v interface{}) map[string]reflect.Kind {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	kinds := map[string]reflect.Kind{}
+	for n := 0; n < t.NumField(); n++ {
+		f := t.Field(n)
+		name := jsonFieldName(f)
+		if name == "" {
+			continue
+		}
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		kinds[name] = ft.Kind()
+	}
+	return kinds
+}
+
+// jsonFieldName returns the name of the struct field when encoded as JSON, or an empty
+// string if the field isn't encoded.
+func jsonFieldName(f reflect.StructField) string {
+	if !f.IsExported() {
+		return ""
+	}
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return f.Name
+	}
+	return name
+}
+
+// jsonKind returns the reflect.Kind which a JSON value decodes to by default.
+func jsonKind(raw json.RawMessage) reflect.Kind {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return reflect.Invalid
+	}
+	switch raw[0] {
+	case '"':
+		return reflect.String
+	case 't', 'f':
+		return reflect.Bool
+	case '{', '[', 'n':
+		return reflect.Invalid
+	}
+	return reflect.Float64
+}
+
+// coerceEnv converts the environment variable's value into JSON for the given kind.
+// Kinds without a scalar representation must be set as JSON.
+func coerceEnv(val string, kind reflect.Kind) (json.RawMessage, error) {
+	switch kind {
+	case reflect.String, reflect.Interface:
+		return json.Marshal(val)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(val, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(f)
+	}
+	if !json.Valid([]byte(val)) {
+		return nil, fmt.Errorf("expected json")
+	}
+	return json.RawMessage(val), nil
+}
+
+// MustGetConfig decodes the config for the action into dest in the same manner as
+// GetConfig, panicking if the args or config can't be loaded.  This should only be used
+// for actions which can't run without valid config.
+func MustGetConfig(dest interface{}) {
+	if err := GetConfig(dest); err != nil {
+		panic(fmt.Errorf("unable to load config: %w", err))
+	}
+}
+
+// GetConfigOrDefault decodes the config for the action into dest in the same manner as
+// GetConfig.  If no config is present dest is left untouched, so that any values dest is
+// initialized with act as defaults.  Config keys which are present overwrite defaults.
+func GetConfigOrDefault(dest interface{}) error {
+	args, err := GetArgs()
+	if err != nil {
+		return err
+	}
+	return args.GetConfigOrDefault(dest)
+}
+
+// GetConfigOrDefault decodes the config for the action into dest in the same manner as
+// the package-level GetConfigOrDefault.
+func (a *Args) GetConfigOrDefault(dest interface{}) error {
+	if a == nil || isEmptyJSON(a.Config) {
+		return nil
+	}
+	if err := checkConfigKeys(a.Config); err != nil {
+		return err
+	}
+	return unmarshalInto(a.Config, dest)
+}
+
+// ConfigRaw returns the JSON-encoded config for the action, allowing the config to be
+// forwarded verbatim or inspected for unknown keys.  If no config is present this returns
+// an empty, non-nil json.RawMessage.
+func ConfigRaw() (json.RawMessage, error) {
+	args, err := GetArgs()
+	if err != nil {
+		return nil, err
+	}
+	return args.ConfigRaw(), nil
+}
+
+// ConfigRaw returns the JSON-encoded config for the action in the same manner as the
+// package-level ConfigRaw.
+func (a *Args) ConfigRaw() json.RawMessage {
+	if a == nil || isEmptyJSON(a.Config) {
+		return json.RawMessage{}
+	}
+	return a.Config
+}
+
+// ValidateConfig validates the config for the action against the given JSON schema,
+// returning an error describing every field which doesn't match.  Actions may call this
+// prior to GetConfig to fail early when config drifts from the action's definition.
+// Absent config is validated as an empty object.
+func ValidateConfig(schema []byte) error {
+	args, err := GetArgs()
+	if err != nil {
+		return err
+	}
+	return args.ValidateConfig(schema)
+}
+
+// ValidateConfig validates the config for the action against the given JSON schema in
+// the same manner as the package-level ValidateConfig.
+func (a *Args) ValidateConfig(schema []byte) error {
+	config := a.ConfigRaw()
+	if isEmptyJSON(config) {
+		config = json.RawMessage("{}")
+	}
+	if err := validateSchema(schema, config); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	return nil
+}
+
+// GetConfigField returns the value of a single top-level key within the config for the
+// action, and whether the key is present.  This suits actions whose config contains a
+// few scalar values, without defining a type for the config.
+func GetConfigField(key string) (interface{}, bool, error) {
+	args, err := GetArgs()
+	if err != nil {
+		return nil, false, err
+	}
+	return args.GetConfigField(key)
+}
+
+// GetConfigField returns the value of a single top-level key within the config for the
+// action in the same manner as the package-level GetConfigField.
+func (a *Args) GetConfigField(key string) (interface{}, bool, error) {
+	raw, ok, err := a.configField(key)
+	if err != nil || !ok {
+		return nil, ok, err
+	}
+	var val interface{}
+	if err := json.Unmarshal(raw, &val); err != nil {
+		return nil, true, fmt.Errorf("unable to decode config field %s: %w", key, err)
+	}
+	return val, true, nil
+}
+
+// GetConfigString returns the string value of a single top-level key within the config
+// for the action, and whether the key is present.  This returns an error if the value
+// isn't a string.
+func GetConfigString(key string) (string, bool, error) {
+	args, err := GetArgs()
+	if err != nil {
+		return "", false, err
+	}
+	return args.GetConfigString(key)
+}
+
+// GetConfigString returns the string value of a single top-level key within the config
+// in the same manner as the package-level GetConfigString.
+func (a *Args) GetConfigString(key string) (string, bool, error) {
+	var val string
+	ok, err := a.decodeConfigField(key, &val)
+	return val, ok, err
+}
+
+// GetConfigInt returns the integer value of a single top-level key within the config
+// for the action, and whether the key is present.  This returns an error if the value
+// isn't an integer.
+func GetConfigInt(key string) (int64, bool, error) {
+	args, err := GetArgs()
+	if err != nil {
+		return 0, false, err
+	}
+	return args.GetConfigInt(key)
+}
+
+// GetConfigInt returns the integer value of a single top-level key within the config in
+// the same manner as the package-level GetConfigInt.
+func (a *Args) GetConfigInt(key string) (int64, bool, error) {
+	var val int64
+	ok, err := a.decodeConfigField(key, &val)
+	return val, ok, err
+}
+
+// decodeConfigField decodes the value of the given config key into dest, returning
+// whether the key is present.
+func (a *Args) decodeConfigField(key string, dest interface{}) (bool, error) {
+	raw, ok, err := a.configField(key)
+	if err != nil || !ok {
+		return ok, err
+	}
+	if err := json.Unmarshal(raw, dest); err != nil {
+		return true, fmt.Errorf("unable to decode config field %s: %w", key, err)
+	}
+	return true, nil
+}
+
+// configField returns the raw value of the given config key, and whether the key is
+// present.
+func (a *Args) configField(key string) (json.RawMessage, bool, error) {
+	config := a.ConfigRaw()
+	if isEmptyJSON(config) {
+		return nil, false, nil
+	}
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(config, &fields); err != nil {
+		return nil, false, fmt.Errorf("unable to decode config: %w", err)
+	}
+	raw, ok := fields[key]
+	return raw, ok, nil
+}
+
+// isEmptyJSON returns whether the given JSON is absent or null.
+func isEmptyJSON(byt json.RawMessage) bool {
+	byt = bytes.TrimSpace(byt)
+	return len(byt) == 0 || bytes.Equal(byt, []byte("null"))
+}
+
+// ConfigOption configures LoadConfig.
+type ConfigOption func(*loadConfigOptions)
+
+type loadConfigOptions struct {
+	debug io.Writer
+}
+
+// WithConfigDebug writes the source of each field's value to w, eg. "Retries: env
+// ACTION_RETRIES".  This defaults to stderr if DebugEnv is set.
+func WithConfigDebug(w io.Writer) ConfigOption {
+	return func(o *loadConfigOptions) {
+		o.debug = w
+	}
+}
+
+// LoadConfig populates the struct pointed to by dest with the action's effective config,
+// layering each of the following sources in order:
+//
+//  1. Defaults, from each field's `default` tag.
+//  2. The config for the action, as decoded by GetConfig.
+//  3. Environment variables, named by each field's `env` tag.  Fields without an env
+//     tag may be overridden using the prefix set via SetConfigEnvPrefix.
+//
+// For example:
+//
+//	type Config struct {
+//		URL     string        `json:"url" env:"API_URL"`
+//		Retries int           `json:"retries" default:"3"`
+//		Timeout time.Duration `json:"timeout" default:"30s" env:"API_TIMEOUT"`
+//	}
+//
+// Default and environment values, and string values within the config, are converted
+// to the field's type;  durations are parsed via time.ParseDuration, such that both
+// `default:"30s"` and {"timeout": "45s"} set Timeout.  Types without a scalar
+// representation must be set as JSON.  Only top-level fields are layered.  Unlike
+// GetConfig, absent config or args aren't an error, such that defaults and environment
+// variables alone may populate dest.
+func LoadConfig(dest interface{}, opts ...ConfigOption) error {
+	args, err := GetArgs()
+	if err != nil && !errors.Is(err, ErrNoArgs) {
+		return err
+	}
+	return args.LoadConfig(dest, opts...)
+}
+
+// LoadConfig populates dest with the action's effective config in the same manner as the
+// package-level LoadConfig.
+func (a *Args) LoadConfig(dest interface{}, opts ...ConfigOption) error {
+	o := &loadConfigOptions{}
+	if debugEnabled() {
+		o.debug = os.Stderr
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("config destination must be a non-nil pointer to a struct, got %T", dest)
+	}
+	rv = rv.Elem()
+	rt := rv.Type()
+	sources := make([]string, rt.NumField())
+
+	for n := 0; n < rt.NumField(); n++ {
+		f := rt.Field(n)
+		def, ok := f.Tag.Lookup("default")
+		if !ok || !f.IsExported() {
+			continue
+		}
+		if err := setFromString(rv.Field(n), def); err != nil {
+			return fmt.Errorf("invalid default for %s: %w", f.Name, err)
+		}
+		sources[n] = "default"
+	}
+
+	if config := a.ConfigRaw(); !isEmptyJSON(config) {
+		if err := checkConfigKeys(config); err != nil {
+			return err
+		}
+		if err := loadConfigFields(rv, config, sources); err != nil {
+			return err
+		}
+	}
+
+	for n := 0; n < rt.NumField(); n++ {
+		f := rt.Field(n)
+		env := f.Tag.Get("env")
+		if env == "" && configEnvPrefix != "" {
+			if name := jsonFieldName(f); name != "" {
+				env = configEnvPrefix + strings.ToUpper(name)
+			}
+		}
+		if env == "" || env == "-" || !f.IsExported() {
+			continue
+		}
+		val, ok := os.LookupEnv(env)
+		if !ok {
+			continue
+		}
+		if err := setFromString(rv.Field(n), val); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", env, err)
+		}
+		sources[n] = "env " + env
+	}
+
+	if o.debug != nil {
+		for n, source := range sources {
+			if !rt.Field(n).IsExported() {
+				continue
+			}
+			if source == "" {
+				source = "unset"
+			}
+			fmt.Fprintf(o.debug, "%s: %s\n", rt.Field(n).Name, source)
+		}
+	}
+	return nil
+}
+
+// loadConfigFields sets each top-level field of the struct rv from the config, recording
+// "config" within sources for each field set.  Fields are matched to keys in the same
+// manner as encoding/json, preferring an exact match over a case-insensitive match.
+func loadConfigFields(rv reflect.Value, config json.RawMessage, sources []string) error {
+	values := map[string]json.RawMessage{}
+	if err := decodeJSON(config, &values); err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	rt := rv.Type()
+	used := map[string]bool{}
+	for n := 0; n < rt.NumField(); n++ {
+		name := jsonFieldName(rt.Field(n))
+		if name == "" {
+			continue
+		}
+		key, ok := name, false
+		if _, ok = values[name]; !ok {
+			for _, k := range keys {
+				if strings.EqualFold(k, name) {
+					key, ok = k, true
+					break
+				}
+			}
+		}
+		if !ok {
+			continue
+		}
+		used[key] = true
+		if err := setFromJSON(rv.Field(n), values[key]); err != nil {
+			return fmt.Errorf("invalid config for %s: %w", key, err)
+		}
+		sources[n] = "config"
+	}
+
+	if strictDecoding.Load() {
+		for _, key := range keys {
+			if !used[key] {
+				return fmt.Errorf("json: unknown field %q", key)
+			}
+		}
+	}
+	return nil
+}
+
+// setFromJSON sets the value from JSON.  Strings are converted via setFromString if the
+// value has a scalar type, such that durations and numbers may be written as strings.
+func setFromJSON(v reflect.Value, raw json.RawMessage) error {
+	if jsonKind(raw) == reflect.String && isScalar(v.Type()) {
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return err
+		}
+		return setFromString(v, s)
+	}
+	return unmarshalInto(raw, v.Addr().Interface())
+}
+
+// isScalar returns whether values of type t are set via setFromString, rather than
+// unmarshalled from JSON.
+func isScalar(t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == reflect.TypeOf(time.Duration(0)) {
+		return true
+	}
+	// Types which decode themselves, such as time.Time, are always unmarshalled.
+	p := reflect.PointerTo(t)
+	if p.Implements(reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()) ||
+		p.Implements(reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()) {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// setFromString sets the value from its string representation.
+func setFromString(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return setFromString(v.Elem(), s)
+	}
+
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return json.Unmarshal([]byte(s), v.Addr().Interface())
+	}
+	return nil
+}
+
+// checkConfigKeys returns ErrDuplicateConfigKey if enabled via
+// SetRejectDuplicateConfigKeys and any object within the config contains the same key
+// more than once.
+func checkConfigKeys(config json.RawMessage) error {
+	if !rejectDuplicateKeys.Load() || isEmptyJSON(config) {
+		return nil
+	}
+	err := checkDuplicateKeys(json.NewDecoder(bytes.NewReader(config)), "")
+	if errors.Is(err, ErrDuplicateConfigKey) {
+		return err
+	}
+	// Syntax errors are reported when decoding.
+	return nil
+}
+
+// checkDuplicateKeys reads a single JSON value from dec, returning ErrDuplicateConfigKey
+// naming the path of the first key duplicated within an object.
+func checkDuplicateKeys(dec *json.Decoder, path string) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	switch tok {
+	case json.Delim('{'):
+		seen := map[string]struct{}{}
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			key, _ := tok.(string)
+			keyPath := key
+			if path != "" {
+				keyPath = path + "." + key
+			}
+			if _, ok := seen[key]; ok {
+				return fmt.Errorf("%w: %s", ErrDuplicateConfigKey, keyPath)
+			}
+			seen[key] = struct{}{}
+			if err := checkDuplicateKeys(dec, keyPath); err != nil {
+				return err
+			}
+		}
+		_, err = dec.Token()
+		return err
+	case json.Delim('['):
+		for n := 0; dec.More(); n++ {
+			if err := checkDuplicateKeys(dec, fmt.Sprintf("%s[%d]", path, n)); err != nil {
+				return err
+			}
+		}
+		_, err = dec.Token()
+		return err
+	}
+	return nil
+}
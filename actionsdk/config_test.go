@@ -0,0 +1,173 @@
+package actionsdk
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateConfig(t *testing.T) {
+	schema := []byte(`{"type":"object","required":["limit"],"properties":{"limit":{"type":"integer"}}}`)
+
+	tests := []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{name: "valid", config: `{"limit":5}`},
+		{name: "missing required field", config: `{}`, wantErr: "limit: is required"},
+		{name: "type mismatch", config: `{"limit":"5"}`, wantErr: "limit: expected integer, got string"},
+		{name: "absent config", config: ``, wantErr: "limit: is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &Args{Config: json.RawMessage(tt.config)}
+			err := a.ValidateConfig(schema)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+type loadedConfig struct {
+	URL     string        `json:"url" env:"TEST_API_URL"`
+	Retries int           `json:"retries" default:"3"`
+	Timeout time.Duration `json:"timeout" default:"30s" env:"TEST_API_TIMEOUT"`
+	Tags    []string      `json:"tags"`
+}
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    *Args
+		env     map[string]string
+		want    loadedConfig
+		wantErr bool
+	}{
+		{
+			name: "missing args",
+			args: nil,
+			want: loadedConfig{Retries: 3, Timeout: 30 * time.Second},
+		},
+		{
+			name: "defaults",
+			args: &Args{},
+			want: loadedConfig{Retries: 3, Timeout: 30 * time.Second},
+		},
+		{
+			name: "config overrides defaults",
+			args: &Args{Config: json.RawMessage(`{"url":"https://a","retries":5,"tags":["x"]}`)},
+			want: loadedConfig{URL: "https://a", Retries: 5, Timeout: 30 * time.Second, Tags: []string{"x"}},
+		},
+		{
+			name: "duration string within config",
+			args: &Args{Config: json.RawMessage(`{"timeout":"45s"}`)},
+			want: loadedConfig{Retries: 3, Timeout: 45 * time.Second},
+		},
+		{
+			name: "numeric string within config",
+			args: &Args{Config: json.RawMessage(`{"retries":"7"}`)},
+			want: loadedConfig{Retries: 7, Timeout: 30 * time.Second},
+		},
+		{
+			name: "env overrides config",
+			args: &Args{Config: json.RawMessage(`{"url":"https://a","timeout":"45s"}`)},
+			env:  map[string]string{"TEST_API_URL": "https://b", "TEST_API_TIMEOUT": "1m"},
+			want: loadedConfig{URL: "https://b", Retries: 3, Timeout: time.Minute},
+		},
+		{
+			name:    "invalid env",
+			args:    &Args{},
+			env:     map[string]string{"TEST_API_TIMEOUT": "soon"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid config",
+			args:    &Args{Config: json.RawMessage(`{"timeout":"soon"}`)},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			got := loadedConfig{}
+			err := tt.args.LoadConfig(&got)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("unexpected config:\n got: %+v\nwant: %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfigDebug(t *testing.T) {
+	t.Setenv("TEST_API_TIMEOUT", "1m")
+	buf := &bytes.Buffer{}
+	a := &Args{Config: json.RawMessage(`{"url":"https://a"}`)}
+	if err := a.LoadConfig(&loadedConfig{}, WithConfigDebug(buf)); err != nil {
+		t.Fatal(err)
+	}
+	want := "URL: config\nRetries: default\nTimeout: env TEST_API_TIMEOUT\nTags: unset\n"
+	if buf.String() != want {
+		t.Fatalf("expected %q, got %q", want, buf.String())
+	}
+}
+
+func TestRejectDuplicateConfigKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		enabled bool
+		wantErr string
+	}{
+		{name: "unique keys", config: `{"a":1,"b":{"a":2}}`, enabled: true},
+		{name: "disabled", config: `{"a":1,"a":2}`, enabled: false},
+		{name: "top-level", config: `{"a":1,"a":2}`, enabled: true, wantErr: "duplicate config key: a"},
+		{name: "nested", config: `{"b":{"x":[{"y":1,"y":2}]}}`, enabled: true, wantErr: "duplicate config key: b.x[0].y"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetRejectDuplicateConfigKeys(tt.enabled)
+			defer SetRejectDuplicateConfigKeys(false)
+
+			a := &Args{Config: json.RawMessage(tt.config)}
+			dest := map[string]interface{}{}
+			errs := map[string]error{
+				"GetConfig":  a.GetConfig(&dest),
+				"LoadConfig": a.LoadConfig(&loadedConfig{}),
+			}
+			for name, err := range errs {
+				if tt.wantErr == "" {
+					if err != nil {
+						t.Fatalf("expected no error from %s, got %v", name, err)
+					}
+					continue
+				}
+				if !errors.Is(err, ErrDuplicateConfigKey) || err.Error() != tt.wantErr {
+					t.Fatalf("expected %q from %s, got %v", tt.wantErr, name, err)
+				}
+			}
+		})
+	}
+}
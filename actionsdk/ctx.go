@@ -0,0 +1,186 @@
+package actionsdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// DeadlineKey is the key within Args.Ctx containing the time by which the step
+	// must complete, as either an RFC 3339 string or Unix milliseconds.
+	DeadlineKey = "deadline"
+
+	// TimeoutKey is the key within Args.Ctx containing the step's timeout relative
+	// to the step starting, as either a duration string ("30s") or seconds.
+	TimeoutKey = "timeout"
+
+	// RunIDKey is the key within Args.Ctx containing the ID of the function run which
+	// the step belongs to.
+	RunIDKey = "run_id"
+
+	// WorkflowIDKey is the key within Args.Ctx containing the ID of the workflow, or
+	// function, which the step belongs to.
+	WorkflowIDKey = "workflow_id"
+
+	// StepIDKey is the key within Args.Ctx containing the ID of the step which is
+	// currently running.
+	StepIDKey = "step_id"
+)
+
+var (
+	// ErrCtxValueMissing is returned when a value isn't present within Args.Ctx.
+	ErrCtxValueMissing = errors.New("value not present in context")
+)
+
+type argsContextKey struct{}
+
+var (
+	// started records when the process started, which timeouts are relative to.
+	started = time.Now()
+)
+
+// WithArgs returns a copy of the context carrying the given args, which can be retrieved
+// via ArgsFromContext.  Run stores the action's args within the handler's context, such
+// that handlers may read the args without relying on package-level state.
+func WithArgs(ctx context.Context, a *Args) context.Context {
+	return context.WithValue(ctx, argsContextKey{}, a)
+}
+
+// ArgsFromContext returns the args stored within the context by WithArgs.
+func ArgsFromContext(ctx context.Context) (*Args, bool) {
+	a, ok := ctx.Value(argsContextKey{}).(*Args)
+	return a, ok && a != nil
+}
+
+// EventFromContext returns the event which triggered the function from the args stored
+// within the context by WithArgs.  As with GetEvent, this returns the first event if the
+// function was triggered by a batch of events.
+func EventFromContext(ctx context.Context) (Event, bool) {
+	a, ok := ArgsFromContext(ctx)
+	if !ok {
+		return Event{}, false
+	}
+	evt, err := a.GetEvent()
+	if err != nil {
+		return Event{}, false
+	}
+	return evt, true
+}
+
+// RunID returns the ID of the function run which the step belongs to, which is stable
+// across each step and retry within the run and may be used to correlate logs and
+// downstream calls.  This returns ErrCtxValueMissing if the engine didn't send the ID.
+func RunID() (string, error) {
+	args, err := GetArgs()
+	if err != nil {
+		return "", err
+	}
+	return args.RunID()
+}
+
+// WorkflowID returns the ID of the workflow, or function, which the step belongs to.
+// This returns ErrCtxValueMissing if the engine didn't send the ID.
+func WorkflowID() (string, error) {
+	args, err := GetArgs()
+	if err != nil {
+		return "", err
+	}
+	return args.WorkflowID()
+}
+
+// CurrentStepID returns the ID of the step which is currently running, as used to key
+// the step's output within Args.Steps.  Combined with GetStepOutput this allows a
+// retried step to detect and reuse work from a previous attempt.  This returns
+// ErrCtxValueMissing if the engine didn't send the ID.
+func CurrentStepID() (string, error) {
+	args, err := GetArgs()
+	if err != nil {
+		return "", err
+	}
+	return args.CurrentStepID()
+}
+
+// RunID returns the ID of the function run which the step belongs to in the same manner
+// as the package-level RunID.
+func (a *Args) RunID() (string, error) {
+	return a.ctxString(RunIDKey)
+}
+
+// WorkflowID returns the ID of the workflow which the step belongs to in the same manner
+// as the package-level WorkflowID.
+func (a *Args) WorkflowID() (string, error) {
+	return a.ctxString(WorkflowIDKey)
+}
+
+// CurrentStepID returns the ID of the step which is currently running in the same
+// manner as the package-level CurrentStepID.
+func (a *Args) CurrentStepID() (string, error) {
+	return a.ctxString(StepIDKey)
+}
+
+// ctxString returns the non-empty string stored under the given key within Args.Ctx.
+func (a *Args) ctxString(key string) (string, error) {
+	var s string
+	if a != nil {
+		s, _ = a.Ctx[key].(string)
+	}
+	if s == "" {
+		return "", fmt.Errorf("%w: %s", ErrCtxValueMissing, key)
+	}
+	return s, nil
+}
+
+// Deadline returns the time by which the step must complete, as assigned by the engine,
+// and whether a deadline is present.  Run cancels the handler's context once the
+// deadline passes.
+func Deadline() (time.Time, bool) {
+	args, err := GetArgs()
+	if err != nil {
+		return time.Time{}, false
+	}
+	return args.Deadline()
+}
+
+// Deadline returns the time by which the step must complete in the same manner as the
+// package-level Deadline.  Timeouts are relative to the process starting.
+func (a *Args) Deadline() (time.Time, bool) {
+	return a.deadline(started)
+}
+
+// deadline returns the time by which the step must complete, with timeouts relative to
+// the given start time.
+func (a *Args) deadline(start time.Time) (time.Time, bool) {
+	if a == nil {
+		return time.Time{}, false
+	}
+
+	switch v := a.Ctx[DeadlineKey].(type) {
+	case string:
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return t, true
+		}
+	case float64:
+		return time.UnixMilli(int64(v)), true
+	case json.Number:
+		if ms, err := v.Int64(); err == nil {
+			return time.UnixMilli(ms), true
+		}
+	}
+
+	switch v := a.Ctx[TimeoutKey].(type) {
+	case string:
+		if d, err := time.ParseDuration(v); err == nil {
+			return start.Add(d), true
+		}
+	case float64:
+		return start.Add(time.Duration(v * float64(time.Second))), true
+	case json.Number:
+		if secs, err := v.Float64(); err == nil {
+			return start.Add(time.Duration(secs * float64(time.Second))), true
+		}
+	}
+	return time.Time{}, false
+}
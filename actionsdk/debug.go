@@ -0,0 +1,147 @@
+package actionsdk
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+)
+
+const (
+	// DebugEnv is the environment variable which, when set to anything other than an
+	// empty string, "0" or "false", dumps the args to stderr when they're loaded.  Dumps
+	// include event data, which may contain personal information, so this is specific
+	// to the SDK rather than the DEBUG variable used by many other tools.
+	DebugEnv = "INNGEST_ACTIONSDK_DEBUG"
+)
+
+// secretKeys are the words within keys whose values are redacted within DumpArgs.  Keys
+// are split into words on punctuation and camel case, such that "apiKey", "api_key" and
+// "x-auth-token" are redacted while "author" isn't.
+var secretKeys = map[string]bool{
+	"secret": true, "secrets": true, "password": true, "passwd": true, "token": true,
+	"tokens": true, "apikey": true, "auth": true, "oauth": true, "authorization": true,
+	"credential": true, "credentials": true, "private": true, "privatekey": true,
+}
+
+// DumpArgs writes the parsed args as indented JSON to the given writer, for diagnosing
+// what the SDK parsed.  Values whose keys look like secrets, and the values of secrets
+// read via GetSecret, are redacted.
+//
+// If the INNGEST_ACTIONSDK_DEBUG environment variable is set the args are dumped to
+// stderr automatically when they're first loaded.
+func DumpArgs(w io.Writer) error {
+	a, err := GetArgs()
+	if err != nil {
+		return err
+	}
+	return a.DumpArgs(w)
+}
+
+// DumpArgs writes the args as indented JSON to the given writer, with secrets redacted,
+// in the same manner as the package-level DumpArgs.
+func (a *Args) DumpArgs(w io.Writer) error {
+	return dumpArgs(w, a)
+}
+
+// dumpArgs writes the given args as indented JSON with secrets redacted.
+func dumpArgs(w io.Writer, a *Args) error {
+	byt, err := json.Marshal(a)
+	if err != nil {
+		return fmt.Errorf("unable to encode args: %w", err)
+	}
+
+	var doc interface{}
+	dec := json.NewDecoder(bytes.NewReader(byt))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return fmt.Errorf("unable to encode args: %w", err)
+	}
+
+	byt, err = json.MarshalIndent(redactSecretKeys(doc), "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to encode args: %w", err)
+	}
+	_, err = fmt.Fprintln(w, RedactSecrets(string(byt)))
+	return err
+}
+
+// redactSecretKeys replaces the values of any keys which look like secrets within the
+// decoded JSON document.
+func redactSecretKeys(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, val := range t {
+			if isSecretKey(k) {
+				if val != nil {
+					t[k] = redacted
+				}
+				continue
+			}
+			t[k] = redactSecretKeys(val)
+		}
+	case []interface{}:
+		for i, val := range t {
+			t[i] = redactSecretKeys(val)
+		}
+	}
+	return v
+}
+
+// isSecretKey returns whether the key looks like it refers to a secret, ie. whether any
+// word within the key, or any pair of adjacent words such as "api" and "key", is within
+// secretKeys.
+func isSecretKey(k string) bool {
+	words := keyWords(k)
+	for n, w := range words {
+		if secretKeys[w] || (n > 0 && secretKeys[words[n-1]+w]) {
+			return true
+		}
+	}
+	return false
+}
+
+// keyWords splits the key into lower case words, separated by any non-alphanumeric
+// characters or camel case boundaries:  "X-APIKey_value" is split into "x", "api", "key"
+// and "value".
+func keyWords(k string) []string {
+	words := []string{}
+	runes := []rune(k)
+	start := -1
+	for n, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if start >= 0 {
+				words = append(words, strings.ToLower(string(runes[start:n])))
+				start = -1
+			}
+			continue
+		}
+		if start >= 0 && unicode.IsUpper(r) {
+			prev := runes[n-1]
+			nextLower := n+1 < len(runes) && unicode.IsLower(runes[n+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				words = append(words, strings.ToLower(string(runes[start:n])))
+				start = n
+			}
+		}
+		if start < 0 {
+			start = n
+		}
+	}
+	if start >= 0 {
+		words = append(words, strings.ToLower(string(runes[start:])))
+	}
+	return words
+}
+
+// debugEnabled returns whether INNGEST_ACTIONSDK_DEBUG is set within the environment.
+func debugEnabled() bool {
+	switch strings.ToLower(os.Getenv(DebugEnv)) {
+	case "", "0", "false":
+		return false
+	}
+	return true
+}
@@ -0,0 +1,63 @@
+package actionsdk
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestIsSecretKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{key: "password", want: true},
+		{key: "apiKey", want: true},
+		{key: "api_key", want: true},
+		{key: "X-APIKey", want: true},
+		{key: "x-auth-token", want: true},
+		{key: "client_secret", want: true},
+		{key: "privateKey", want: true},
+		{key: "Authorization", want: true},
+		{key: "author", want: false},
+		{key: "tokenizer", want: false},
+		{key: "keyboard", want: false},
+		{key: "key", want: false},
+		{key: "name", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := isSecretKey(tt.key); got != tt.want {
+				t.Fatalf("isSecretKey(%q) = %v, want %v", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDumpArgs(t *testing.T) {
+	a := &Args{
+		Event: Event{Name: "user/created", Data: map[string]interface{}{
+			"author":  "ada",
+			"account": map[string]interface{}{"apiKey": "k_123", "ids": []interface{}{1}},
+			"tokens":  []interface{}{"t_1", "t_2"},
+		}},
+		Config: json.RawMessage(`{"password":"hunter2","empty_secret":null}`),
+	}
+	buf := &bytes.Buffer{}
+	if err := a.DumpArgs(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	for _, secret := range []string{"k_123", "t_1", "hunter2"} {
+		if strings.Contains(out, secret) {
+			t.Fatalf("expected %q to be redacted within %s", secret, out)
+		}
+	}
+	for _, value := range []string{`"author": "ada"`, `"empty_secret": null`, `"user/created"`} {
+		if !strings.Contains(out, value) {
+			t.Fatalf("expected %s within %s", value, out)
+		}
+	}
+}
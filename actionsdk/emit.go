@@ -0,0 +1,75 @@
+package actionsdk
+
+import (
+	"sync"
+)
+
+var (
+	// emittedMu guards the events buffered within each Args via EmitEvent.
+	emittedMu sync.Mutex
+)
+
+// EmitEvent buffers an event to be sent by the engine once the step completes, allowing
+// a step to trigger downstream functions.  Events may be emitted multiple times prior
+// to writing a result;  each buffered event is included within the result written by
+// WriteResult under the "events" key:
+//
+//	{"body": {...}, "status": 200, "events": [{"name": "order/shipped", "data": {...}}]}
+//
+// Events are buffered within the args returned by GetArgs, and are discarded once the
+// result is written.  This returns an error if the args can't be loaded.  Handlers run
+// via Serve must use (*Args).EmitEvent with the args from ArgsFromContext instead.
+func EmitEvent(e Event) error {
+	args, err := GetArgs()
+	if err != nil {
+		return err
+	}
+	args.EmitEvent(e)
+	return nil
+}
+
+// EmitEvent buffers an event to be sent by the engine once the step completes, in the
+// same manner as the package-level EmitEvent.  Events emitted within handlers run via
+// Serve are included within the request's response.
+func (a *Args) EmitEvent(e Event) {
+	if a == nil {
+		return
+	}
+	emittedMu.Lock()
+	defer emittedMu.Unlock()
+	a.emitted = append(a.emitted, e)
+}
+
+// takeEmittedEvents returns and discards the events buffered within the args.
+func (a *Args) takeEmittedEvents() []Event {
+	if a == nil {
+		return nil
+	}
+	emittedMu.Lock()
+	defer emittedMu.Unlock()
+	evts := a.emitted
+	a.emitted = nil
+	return evts
+}
+
+// emittedEvents returns the events buffered within the args returned by GetArgs.
+func emittedEvents() []Event {
+	argsMu.Lock()
+	a := args
+	argsMu.Unlock()
+	if a == nil {
+		return nil
+	}
+
+	emittedMu.Lock()
+	defer emittedMu.Unlock()
+	return append([]Event(nil), a.emitted...)
+}
+
+// clearEmittedEvents discards the events buffered within the args returned by GetArgs.
+func clearEmittedEvents() {
+	argsMu.Lock()
+	a := args
+	argsMu.Unlock()
+	a.takeEmittedEvents()
+}
@@ -0,0 +1,112 @@
+package actionsdk
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Error codes categorize failures consistently across actions, allowing the engine and
+// dashboards to group errors.  Errors carrying a code are created via ValidationError,
+// TimeoutError, UpstreamError and InternalError, or directly as a StructuredError.
+const (
+	// CodeValidation indicates that the step's input is invalid.
+	CodeValidation = "validation"
+	// CodeTimeout indicates that the step, or an operation within it, timed out.
+	CodeTimeout = "timeout"
+	// CodeUpstream indicates that a service called by the step failed.
+	CodeUpstream = "upstream"
+	// CodeInternal indicates an unexpected failure within the step.
+	CodeInternal = "internal"
+)
+
+// ValidationError returns a non-retryable error with CodeValidation, as retrying a
+// step with invalid input fails in the same manner.
+func ValidationError(msg string) error {
+	return StructuredError{Message: msg, Code: CodeValidation}
+}
+
+// TimeoutError returns a retryable error with CodeTimeout.
+func TimeoutError(msg string) error {
+	return StructuredError{Message: msg, Code: CodeTimeout, Retryable: true}
+}
+
+// UpstreamError returns a retryable error with CodeUpstream.
+func UpstreamError(msg string) error {
+	return StructuredError{Message: msg, Code: CodeUpstream, Retryable: true}
+}
+
+// InternalError returns a non-retryable error with CodeInternal.
+func InternalError(msg string) error {
+	return StructuredError{Message: msg, Code: CodeInternal}
+}
+
+// RetryableError wraps an error which the engine should retry, regardless of the
+// retryable flag passed when writing the error.
+type RetryableError struct {
+	Err error
+}
+
+// Retryable marks the given error as retryable.
+func Retryable(err error) error {
+	return &RetryableError{Err: err}
+}
+
+func (e *RetryableError) Error() string { return e.Err.Error() }
+func (e *RetryableError) Unwrap() error { return e.Err }
+
+// NonRetryableError wraps an error which represents a permanent failure that the
+// engine should not retry, regardless of the retryable flag passed when writing the
+// error.  For example, a 4xx response from an upstream API may be non-retryable.
+type NonRetryableError struct {
+	Err error
+}
+
+// NonRetryable marks the given error as non-retryable.
+func NonRetryable(err error) error {
+	return &NonRetryableError{Err: err}
+}
+
+func (e *NonRetryableError) Error() string { return e.Err.Error() }
+func (e *NonRetryableError) Unwrap() error { return e.Err }
+
+// PanicError is an error recovered from a panic, including the stack trace at the time
+// of the panic.  The stack is written alongside the error by WriteError.
+type PanicError struct {
+	Value interface{}
+	Stack string
+}
+
+func (e *PanicError) Error() string { return fmt.Sprintf("panic: %v", e.Value) }
+
+// asStructuredError returns the StructuredError within the error chain, whether it's
+// wrapped as a value or a pointer.
+func asStructuredError(err error) (StructuredError, bool) {
+	var serr StructuredError
+	if errors.As(err, &serr) {
+		return serr, true
+	}
+	var perr *StructuredError
+	if errors.As(err, &perr) && perr != nil {
+		return *perr, true
+	}
+	return StructuredError{}, false
+}
+
+// isRetryable returns whether err should be retried.  Errors wrapping a RetryableError,
+// NonRetryableError or StructuredError use the wrapped retryability, falling back to def
+// otherwise.  The outermost wrapper within the error chain takes precedence.
+func isRetryable(err error, def bool) bool {
+	for ; err != nil; err = errors.Unwrap(err) {
+		switch e := err.(type) {
+		case *RetryableError:
+			return true
+		case *NonRetryableError:
+			return false
+		case StructuredError:
+			return e.Retryable
+		case *StructuredError:
+			return e.Retryable
+		}
+	}
+	return def
+}
@@ -0,0 +1,489 @@
+package actionsdk
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidEvent is returned when an event is missing required fields.
+	ErrInvalidEvent = errors.New("invalid event")
+)
+
+// Event is the triggering event for this function.
+type Event struct {
+	Name      string                 `json:"name"`
+	Data      map[string]interface{} `json:"data"`
+	User      map[string]interface{} `json:"user,omitempty"`
+	ID        string                 `json:"id,omitempty"`
+	Timestamp int64                  `json:"ts,omitempty"`
+	Version   string                 `json:"v,omitempty"`
+}
+
+// UnmarshalJSON decodes the event, accepting the long "timestamp" and "version" keys
+// emitted by some producers as well as the short "ts" and "v" wire keys.  The short
+// keys take precedence if both are present.  Marshal always writes the short keys.
+func (e *Event) UnmarshalJSON(byt []byte) error {
+	type event Event
+	v := struct {
+		*event
+		Timestamp int64  `json:"timestamp"`
+		Version   string `json:"version"`
+	}{event: (*event)(e)}
+	if err := decodeJSON(byt, &v); err != nil {
+		return err
+	}
+	if e.Timestamp == 0 {
+		e.Timestamp = v.Timestamp
+	}
+	if e.Version == "" {
+		e.Version = v.Version
+	}
+	return nil
+}
+
+// MarshalJSON encodes the event using the short wire keys.  User is omitted only if
+// it's nil, such that an empty User map is kept when re-encoding a parsed event.
+func (e Event) MarshalJSON() ([]byte, error) {
+	type event Event
+	if e.User == nil {
+		return marshalJSON(event(e))
+	}
+	return marshalJSON(struct {
+		event
+		User map[string]interface{} `json:"user"`
+	}{event: event(e), User: e.User})
+}
+
+// NewEvent returns an event with the given name and empty data, for constructing events
+// within tests in combination with WithData and WithUser:
+//
+//	evt := actionsdk.NewEvent("order/placed").WithData(OrderPlaced{ID: "o_123"})
+func NewEvent(name string) Event {
+	return Event{Name: name, Data: map[string]interface{}{}}
+}
+
+// WithData returns a copy of the event with its data set to the JSON encoding of v,
+// which must encode to an object.  As this is intended for tests, this panics if v
+// can't be encoded.
+func (e Event) WithData(v interface{}) Event {
+	data := map[string]interface{}{}
+	if err := remarshal(v, &data); err != nil {
+		panic(fmt.Errorf("error encoding event data: %w", err))
+	}
+	e.Data = data
+	return e
+}
+
+// WithUser returns a copy of the event with its user set to the JSON encoding of v,
+// which must encode to an object.  As this is intended for tests, this panics if v
+// can't be encoded.
+func (e Event) WithUser(v interface{}) Event {
+	user := map[string]interface{}{}
+	if err := remarshal(v, &user); err != nil {
+		panic(fmt.Errorf("error encoding event user: %w", err))
+	}
+	e.User = user
+	return e
+}
+
+// Validate checks that the event has a name and that each of the given keys is present
+// within the event's data, returning an error listing every missing field.  This
+// allows handlers to check their preconditions in a single call:
+//
+//	if err := evt.Validate("order_id", "email"); err != nil {
+//		return nil, actionsdk.NonRetryable(err)
+//	}
+func (e Event) Validate(requiredDataKeys ...string) error {
+	missing := []string{}
+	if e.Name == "" {
+		missing = append(missing, "name")
+	}
+	for _, key := range requiredDataKeys {
+		if _, ok := e.Data[key]; !ok {
+			missing = append(missing, "data."+key)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: missing %s", ErrInvalidEvent, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// Marshal returns the event encoded in the wire format used by the engine, allowing
+// actions to forward or re-emit events.  For events parsed from the wire format,
+// decoding the returned JSON into an Event gives an event equal to e.
+func (e Event) Marshal() ([]byte, error) {
+	byt, err := marshalJSON(e)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding event: %w", err)
+	}
+	return byt, nil
+}
+
+// IdempotencyKey returns a key for the event which is stable across retries, for use
+// as a deduplication token when performing side effects such as charging a card.  The
+// key is a hex-encoded SHA-256 hash of the event's ID and the given scope, such that
+// each side effect for an event may use a separate key:
+//
+//	key := evt.IdempotencyKey("charge")
+//
+// If the event has no ID, the key is derived from the event's encoded contents instead.
+func (e Event) IdempotencyKey(scope string) string {
+	id := e.ID
+	if id == "" {
+		byt, _ := e.Marshal()
+		id = string(byt)
+	}
+	sum := sha256.Sum256([]byte(id + "\x00" + scope))
+	return hex.EncodeToString(sum[:])
+}
+
+// Time returns the time the event occurred at, interpreting Timestamp as Unix
+// milliseconds.  This returns the zero time if the event has no timestamp.
+func (e Event) Time() time.Time {
+	if e.Timestamp == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(e.Timestamp).UTC()
+}
+
+// IDTime returns the time embedded within the event's ID.  Event IDs are ULIDs, whose
+// first 48 bits encode the creation time in Unix milliseconds.  This returns an error
+// if the event has no ID or the ID isn't a valid ULID.
+func (e Event) IDTime() (time.Time, error) {
+	if e.ID == "" {
+		return time.Time{}, fmt.Errorf("event has no id")
+	}
+	if len(e.ID) != 26 {
+		return time.Time{}, fmt.Errorf("invalid ulid: %s", e.ID)
+	}
+
+	var ms uint64
+	for n, c := range strings.ToUpper(e.ID) {
+		i := strings.IndexRune(crockford, c)
+		// The first character can only hold 3 bits, as a ULID is 128 bits.
+		if i < 0 || (n == 0 && i > 7) {
+			return time.Time{}, fmt.Errorf("invalid ulid: %s", e.ID)
+		}
+		// The first 10 characters hold the 48 bit timestamp.
+		if n < 10 {
+			ms = ms<<5 | uint64(i)
+		}
+	}
+	return time.UnixMilli(int64(ms)).UTC(), nil
+}
+
+// crockford is the Crockford base32 alphabet used to encode ULIDs.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// SetTime sets the event's Timestamp to the given time with millisecond precision.
+func (e *Event) SetTime(t time.Time) {
+	if t.IsZero() {
+		e.Timestamp = 0
+		return
+	}
+	e.Timestamp = t.UnixMilli()
+}
+
+// DataInto decodes the event's data into dest, which must be a pointer.  If the event
+// has no data dest is left untouched.
+func (e Event) DataInto(dest interface{}) error {
+	if e.Data == nil {
+		return nil
+	}
+	if err := remarshal(e.Data, dest); err != nil {
+		return fmt.Errorf("error decoding event data: %w", err)
+	}
+	return nil
+}
+
+// UserInto decodes the event's user into dest, which must be a pointer.  If the event
+// has no user dest is left untouched.
+func (e Event) UserInto(dest interface{}) error {
+	if e.User == nil {
+		return nil
+	}
+	if err := remarshal(e.User, dest); err != nil {
+		return fmt.Errorf("error decoding event user: %w", err)
+	}
+	return nil
+}
+
+// DataPath returns the value at the given dot-separated path within the event's data,
+// and whether the value is present:
+//
+//	email, ok := evt.DataPath("order.customer.email")
+//
+// Numeric segments index into arrays, eg. "items.0.sku".  This returns false if any
+// segment is missing or refers into a value which isn't an object or array.
+func (e Event) DataPath(path string) (interface{}, bool) {
+	var v interface{} = e.Data
+	for _, key := range strings.Split(path, ".") {
+		switch t := v.(type) {
+		case map[string]interface{}:
+			val, ok := t[key]
+			if !ok {
+				return nil, false
+			}
+			v = val
+		case []interface{}:
+			n, err := strconv.Atoi(key)
+			if err != nil || n < 0 || n >= len(t) {
+				return nil, false
+			}
+			v = t[n]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// DataPathString returns the string at the given path within the event's data, as
+// resolved by DataPath.  This returns false if the value is missing or isn't a string.
+func (e Event) DataPathString(path string) (string, bool) {
+	v, _ := e.DataPath(path)
+	s, ok := v.(string)
+	return s, ok
+}
+
+// DataPathInt returns the integer at the given path within the event's data, as
+// resolved by DataPath.  This returns false if the value is missing or isn't an integer.
+func (e Event) DataPathInt(path string) (int64, bool) {
+	v, _ := e.DataPath(path)
+	switch n := v.(type) {
+	case float64:
+		if n != math.Trunc(n) || n < math.MinInt64 || n >= math.MaxInt64 {
+			return 0, false
+		}
+		return int64(n), true
+	case json.Number:
+		i, err := n.Int64()
+		return i, err == nil
+	}
+	return 0, false
+}
+
+// NameMatches returns whether the event's name matches the given pattern.  Patterns
+// are split into "/" separated segments, where "*" within a segment matches any
+// characters in that segment and a "**" segment matches one or more segments:
+//
+//	order/*            matches "order/placed", but not "order/placed/v2"
+//	user/*.created     matches "user/account.created"
+//	order/**           matches "order/placed" and "order/placed/v2"
+func (e Event) NameMatches(pattern string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(e.Name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for n := 1; n <= len(name); n++ {
+				if matchSegments(pattern[1:], name[n:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], name[0]); err != nil || !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// VersionAtLeast returns whether the event's version is greater than or equal to v.
+// Versions may either be semantic versions ("1.2.0", "v1.2", "1.2.0-rc.1") or date
+// versions ("2021-03-19", "2021-03-19.01"), and both versions must use the same scheme.
+// Pre-release semantic versions have a lower precedence than the associated release, as
+// per the semantic versioning specification.  An error is returned if either version
+// can't be parsed.
+func (e Event) VersionAtLeast(v string) (bool, error) {
+	have, err := parseVersion(e.Version)
+	if err != nil {
+		return false, fmt.Errorf("invalid event version: %w", err)
+	}
+	want, err := parseVersion(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid version: %w", err)
+	}
+	if have.date != want.date {
+		return false, fmt.Errorf("cannot compare versions %q and %q", e.Version, v)
+	}
+	return have.compare(want) >= 0, nil
+}
+
+// version is a parsed semantic or date version.
+type version struct {
+	// nums contains the numeric components of the version.
+	nums []int
+	// pre contains the dot-separated pre-release identifiers of a semantic version.
+	pre []string
+	// date is whether this is a date version.
+	date bool
+}
+
+// compare returns -1, 0 or 1 if v has a lower, equal or higher precedence than o.
+func (v version) compare(o version) int {
+	for n := range v.nums {
+		if v.nums[n] != o.nums[n] {
+			return sign(v.nums[n] - o.nums[n])
+		}
+	}
+
+	// A version without pre-release identifiers takes precedence over one with them.
+	switch {
+	case len(v.pre) == 0 && len(o.pre) == 0:
+		return 0
+	case len(v.pre) == 0:
+		return 1
+	case len(o.pre) == 0:
+		return -1
+	}
+	for n := 0; n < len(v.pre) && n < len(o.pre); n++ {
+		if c := comparePrerelease(v.pre[n], o.pre[n]); c != 0 {
+			return c
+		}
+	}
+	return sign(len(v.pre) - len(o.pre))
+}
+
+// comparePrerelease compares two pre-release identifiers.  Numeric identifiers are
+// compared numerically and have a lower precedence than alphanumeric identifiers, which
+// are compared lexically.
+func comparePrerelease(a, b string) int {
+	an, aerr := strconv.Atoi(a)
+	bn, berr := strconv.Atoi(b)
+	switch {
+	case aerr == nil && berr == nil:
+		return sign(an - bn)
+	case aerr == nil:
+		return -1
+	case berr == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func sign(i int) int {
+	switch {
+	case i < 0:
+		return -1
+	case i > 0:
+		return 1
+	}
+	return 0
+}
+
+// parseVersion parses a semantic or date version.  Missing trailing components are
+// treated as zero so that "1.2" equals "1.2.0".
+func parseVersion(v string) (version, error) {
+	var parts []string
+	var pre []string
+	isDate := isDateVersion(v)
+	if isDate {
+		// "YYYY-MM-DD.XX", where the ".XX" revision is optional.
+		date, rev, _ := strings.Cut(v, ".")
+		parts = append(strings.Split(date, "-"), rev)
+		if rev == "" {
+			parts[3] = "0"
+		}
+	} else {
+		v = strings.TrimPrefix(v, "v")
+		// Build metadata doesn't affect precedence.
+		v, _, _ = strings.Cut(v, "+")
+		var prerelease string
+		var hasPre bool
+		v, prerelease, hasPre = strings.Cut(v, "-")
+		if hasPre {
+			pre = strings.Split(prerelease, ".")
+			for _, id := range pre {
+				if !validPrerelease(id) {
+					return version{}, fmt.Errorf("invalid pre-release %q", prerelease)
+				}
+			}
+		}
+		parts = strings.Split(v, ".")
+		if len(parts) > 3 {
+			return version{}, fmt.Errorf("invalid version %q", v)
+		}
+		for len(parts) < 3 {
+			parts = append(parts, "0")
+		}
+	}
+
+	nums := make([]int, len(parts))
+	for n, p := range parts {
+		i, err := strconv.Atoi(p)
+		if err != nil || i < 0 {
+			return version{}, fmt.Errorf("invalid version %q", v)
+		}
+		nums[n] = i
+	}
+	return version{nums: nums, pre: pre, date: isDate}, nil
+}
+
+// isDateVersion returns whether v starts with a "YYYY-MM-DD" date.
+func isDateVersion(v string) bool {
+	if len(v) < 10 || (len(v) > 10 && v[10] != '.') {
+		return false
+	}
+	for n, c := range v[:10] {
+		if n == 4 || n == 7 {
+			if c != '-' {
+				return false
+			}
+		} else if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// validPrerelease returns whether id is a valid pre-release identifier, containing only
+// alphanumerics and hyphens.
+func validPrerelease(id string) bool {
+	if id == "" {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// EventData decodes the event's data into the given type, allowing typed access
+// to the event's fields:
+//
+//	order, err := actionsdk.EventData[OrderPlaced](evt)
+//
+// If the event has no data this returns the zero value of T.
+func EventData[T any](e Event) (T, error) {
+	var dest T
+	err := e.DataInto(&dest)
+	return dest, err
+}
+
+// remarshal converts src into dest by round-tripping it through JSON.
+func remarshal(src, dest interface{}) error {
+	byt, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return unmarshalInto(byt, dest)
+}
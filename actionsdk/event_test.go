@@ -0,0 +1,147 @@
+package actionsdk
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestNameMatches(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{pattern: "order/placed", name: "order/placed", want: true},
+		{pattern: "order/placed", name: "order/cancelled", want: false},
+		{pattern: "order/*", name: "order/placed", want: true},
+		{pattern: "order/*", name: "order/placed/v2", want: false},
+		{pattern: "order/*", name: "order", want: false},
+		{pattern: "user/*.created", name: "user/account.created", want: true},
+		{pattern: "user/*.created", name: "user/account.deleted", want: false},
+		{pattern: "order/**", name: "order/placed", want: true},
+		{pattern: "order/**", name: "order/placed/v2", want: true},
+		{pattern: "order/**", name: "order", want: false},
+		{pattern: "**/placed", name: "shop/order/placed", want: true},
+		{pattern: "order/[", name: "order/[", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.name, func(t *testing.T) {
+			if got := (Event{Name: tt.name}).NameMatches(tt.pattern); got != tt.want {
+				t.Fatalf("NameMatches(%q) on %q = %v, want %v", tt.pattern, tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVersionAtLeast(t *testing.T) {
+	tests := []struct {
+		have    string
+		want    string
+		ok      bool
+		wantErr bool
+	}{
+		{have: "1.2.0", want: "1.2.0", ok: true},
+		{have: "1.10.0", want: "1.9.0", ok: true},
+		{have: "v1.2", want: "1.2.0", ok: true},
+		{have: "1.2.0", want: "1.3", ok: false},
+		{have: "1.2.0+build.5", want: "1.2.0", ok: true},
+		{have: "1.2.0-rc.1", want: "1.2.0", ok: false},
+		{have: "1.2.0", want: "1.2.0-rc.1", ok: true},
+		{have: "1.2.0-rc.2", want: "1.2.0-rc.1", ok: true},
+		{have: "1.2.0-rc.10", want: "1.2.0-rc.9", ok: true},
+		{have: "1.2.0-alpha", want: "1.2.0-1", ok: true},
+		{have: "1.2.0-rc", want: "1.2.0-rc.1", ok: false},
+		{have: "2021-03-19", want: "2021-03-19", ok: true},
+		{have: "2021-03-19.02", want: "2021-03-19.01", ok: true},
+		{have: "2021-03-19", want: "2021-03-19.01", ok: false},
+		{have: "2021-12-01", want: "2021-03-19", ok: true},
+		{have: "2021-03-19", want: "1.2.0", wantErr: true},
+		{have: "1.2.0-rc!", want: "1.2.0", wantErr: true},
+		{have: "1.2.3.4", want: "1.2.0", wantErr: true},
+		{have: "", want: "1.2.0", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.have+" "+tt.want, func(t *testing.T) {
+			ok, err := Event{Version: tt.have}.VersionAtLeast(tt.want)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != tt.ok {
+				t.Fatalf("VersionAtLeast(%q) on %q = %v, want %v", tt.want, tt.have, ok, tt.ok)
+			}
+		})
+	}
+}
+
+func TestEventKeySpellings(t *testing.T) {
+	want := Event{
+		Name:      "order/placed",
+		Data:      map[string]interface{}{"id": float64(1)},
+		ID:        "01FZ7F2R6W8WAYHB7B5KZ3ZPEW",
+		Timestamp: 1616112000000,
+		Version:   "2021-03-19.01",
+	}
+
+	tests := []struct {
+		name    string
+		payload string
+	}{
+		{
+			name:    "short keys",
+			payload: `{"name":"order/placed","data":{"id":1},"id":"01FZ7F2R6W8WAYHB7B5KZ3ZPEW","ts":1616112000000,"v":"2021-03-19.01"}`,
+		},
+		{
+			name:    "long keys",
+			payload: `{"name":"order/placed","data":{"id":1},"id":"01FZ7F2R6W8WAYHB7B5KZ3ZPEW","timestamp":1616112000000,"version":"2021-03-19.01"}`,
+		},
+		{
+			name:    "short keys take precedence",
+			payload: `{"name":"order/placed","data":{"id":1},"id":"01FZ7F2R6W8WAYHB7B5KZ3ZPEW","ts":1616112000000,"timestamp":1,"v":"2021-03-19.01","version":"1.0.0"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Event{}
+			if err := json.Unmarshal([]byte(tt.payload), &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("unexpected event:\n got: %#v\nwant: %#v", got, want)
+			}
+		})
+	}
+}
+
+func TestEventMarshalRoundTrip(t *testing.T) {
+	fixtures := []string{
+		`{"name":"order/placed","data":{"id":1,"items":[{"sku":"a"}]},"user":{"email":"ada@example.com"},"id":"01FZ7F2R6W8WAYHB7B5KZ3ZPEW","ts":1616112000000,"v":"2021-03-19.01"}`,
+		`{"name":"order/placed","data":{}}`,
+		`{"name":"order/placed","data":null,"user":{}}`,
+	}
+	for _, fixture := range fixtures {
+		t.Run(fixture, func(t *testing.T) {
+			parsed := Event{}
+			if err := json.Unmarshal([]byte(fixture), &parsed); err != nil {
+				t.Fatal(err)
+			}
+			byt, err := parsed.Marshal()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := Event{}
+			if err := json.Unmarshal(byt, &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, parsed) {
+				t.Fatalf("unexpected event after round-tripping %s:\n got: %#v\nwant: %#v", byt, got, parsed)
+			}
+		})
+	}
+}
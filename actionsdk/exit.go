@@ -0,0 +1,74 @@
+package actionsdk
+
+import (
+	"os"
+)
+
+const (
+	// ExitContinue is the exit code which stops the action while allowing the
+	// workflow to continue.
+	ExitContinue = 0
+
+	// ExitFailure is the exit code which stops the action and prevents the
+	// workflow branch from continuing.
+	ExitFailure = 1
+)
+
+var (
+	// exit terminates the process.  This is a variable so that tests may prevent
+	// the process from exiting.
+	exit = os.Exit
+)
+
+// StopAndContinue stops the action, allowing the workflow to continue.  Any result
+// should be written via WriteResult prior to calling this, or use StopAndContinueResult
+// to write the result and stop in a single call.
+func StopAndContinue() {
+	flushBeforeExit()
+	exit(ExitContinue)
+}
+
+// StopAndContinueResult writes the given value as the action's result and stops the
+// action, allowing the workflow to continue.  As with Run, a *Result is written as-is
+// while any other value is written as the body of a result with a 200 status.  Output
+// is flushed prior to exiting.  If the result can't be written, eg. as it exceeds the
+// maximum output size, the error is written via StopAndFail instead.
+func StopAndContinueResult(i interface{}) {
+	if err := WriteResult(toResult(i)); err != nil {
+		StopAndFail(err)
+		return
+	}
+	StopAndContinue()
+}
+
+// StopAndFail writes the error via WriteError and stops the action, preventing the
+// workflow branch from continuing.  The error is written as non-retryable unless it wraps
+// a RetryableError or a retryable StructuredError;  use StopAndRetry for errors which
+// should be retried by default.
+func StopAndFail(err error) {
+	stopAndFail(err, false)
+}
+
+// StopAndRetry writes the error via WriteError and stops the action in the same manner as
+// StopAndFail, writing the error as retryable unless it wraps a NonRetryableError or a
+// non-retryable StructuredError.
+func StopAndRetry(err error) {
+	stopAndFail(err, true)
+}
+
+// stopAndFail writes the error, using retryable for errors without a retryability, and
+// stops the action.
+func stopAndFail(err error, retryable bool) {
+	if werr := WriteError(err, retryable); werr != nil {
+		logger.Error("unable to write error", "error", werr, "cause", err)
+	}
+	flushBeforeExit()
+	exit(ExitFailure)
+}
+
+// flushBeforeExit flushes any buffered output, such that output isn't lost on exit.
+func flushBeforeExit() {
+	if err := FlushOutput(); err != nil {
+		logger.Error("unable to flush output", "error", err)
+	}
+}
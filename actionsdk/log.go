@@ -0,0 +1,24 @@
+package actionsdk
+
+import (
+	"io"
+	"log/slog"
+)
+
+var (
+	// logger receives diagnostics from this package.  By default diagnostics are
+	// discarded, as stdout is reserved for the action's output.
+	logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+)
+
+// SetLogger sets the logger which receives diagnostics from this package, such as
+// failures to write output or migrations of legacy args.  Passing nil discards
+// diagnostics.
+//
+// This is not safe to call concurrently with other functions in this package.
+func SetLogger(l *slog.Logger) {
+	if l == nil {
+		l = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	logger = l
+}
@@ -0,0 +1,45 @@
+package actionsdk
+
+import (
+	"encoding/json"
+)
+
+// legacyArgs is the unversioned envelope sent by older engines, which nests the
+// triggering event and the output of previous steps within "baggage" and sends the
+// step's config as "metadata".
+type legacyArgs struct {
+	Metadata json.RawMessage `json:"metadata"`
+	Baggage  *struct {
+		Event   Event                             `json:"event"`
+		Actions map[string]map[string]interface{} `json:"actions"`
+	} `json:"baggage"`
+}
+
+// migrateArgs decodes the raw args envelope of the given version, transforming older
+// envelope shapes into the current Args so that callers always receive args in a
+// normalized form.
+func migrateArgs(version int, raw json.RawMessage) (*Args, error) {
+	if version == 0 {
+		legacy := &legacyArgs{}
+		if err := decodeJSON(raw, legacy); err != nil {
+			return nil, err
+		}
+		if legacy.Baggage != nil {
+			logger.Warn("migrating legacy args envelope", "version", version)
+			return &Args{
+				Event:   legacy.Baggage.Event,
+				Steps:   legacy.Baggage.Actions,
+				Config:  legacy.Metadata,
+				Version: SupportedArgsVersion,
+			}, nil
+		}
+	}
+
+	// Unversioned args without baggage share the shape of the current version.
+	a := &Args{}
+	if err := decodeJSON(raw, a); err != nil {
+		return nil, err
+	}
+	a.Version = SupportedArgsVersion
+	return a, nil
+}
@@ -0,0 +1,48 @@
+package actionsdk
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMigrateArgs(t *testing.T) {
+	want := &Args{
+		Event:   Event{Name: "order/placed", Data: map[string]interface{}{"id": float64(1)}},
+		Steps:   map[string]map[string]interface{}{"1": {"ok": true}},
+		Config:  json.RawMessage(`{"limit":5}`),
+		Version: SupportedArgsVersion,
+	}
+
+	tests := []struct {
+		name    string
+		payload string
+	}{
+		{
+			name:    "legacy baggage",
+			payload: `{"metadata":{"limit":5},"baggage":{"event":{"name":"order/placed","data":{"id":1}},"actions":{"1":{"ok":true}}}}`,
+		},
+		{
+			name:    "unversioned",
+			payload: `{"event":{"name":"order/placed","data":{"id":1}},"steps":{"1":{"ok":true}},"config":{"limit":5}}`,
+		},
+		{
+			name:    "version 1",
+			payload: `{"version":1,"event":{"name":"order/placed","data":{"id":1}},"steps":{"1":{"ok":true}},"config":{"limit":5}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetArgsFromReader(strings.NewReader(tt.payload))
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Only the decoded steps are compared, as legacy args don't retain them raw.
+			got.rawSteps = nil
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("unexpected args:\n got: %#v\nwant: %#v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,674 @@
+package actionsdk
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+)
+
+const (
+	// DefaultMaxOutputSize is the default maximum size of a marshalled result, in bytes.
+	DefaultMaxOutputSize = 4 * 1024 * 1024
+)
+
+var (
+	// ErrResultAlreadyWritten is returned when writing a result after a result has
+	// already been written.
+	ErrResultAlreadyWritten = errors.New("result already written")
+
+	// ErrOutputTooLarge is returned when a marshalled result exceeds the maximum
+	// output size.
+	ErrOutputTooLarge = errors.New("output too large")
+
+	// ErrPartialOutput is returned when writing output after a previous result or error
+	// failed part way through being written.  Nothing further is written, as appending to
+	// the partial output would produce output which the engine can't parse.
+	ErrPartialOutput = errors.New("output partially written")
+
+	// maxOutputSize is the maximum size of a marshalled result, in bytes.
+	maxOutputSize = DefaultMaxOutputSize
+
+	// encode marshals all output written by this package.
+	encode = marshalJSON
+
+	// outputFile is the file output is written to, if set via SetOutputFD.
+	outputFile *os.File
+
+	// buffered buffers writes to the output, and is flushed once each result or error
+	// is written.  bufferedTo is the output which buffered writes to.
+	buffered   *bufio.Writer
+	bufferedTo io.Writer
+	bufferedMu sync.Mutex
+
+	// partialOutput records whether a write failed after writing part of its output.
+	partialOutput bool
+
+	// resultWritten records whether WriteResult has been called successfully.
+	resultWritten bool
+	resultMu      sync.Mutex
+)
+
+// Result is the data returned from this step.
+type Result struct {
+	Body   interface{} `json:"body"`
+	Status int         `json:"status"`
+}
+
+// WriteError writes an error to stdout, or the file descriptor set via SetOutputFD,
+// with a standard format.  The error is added to a json object with an "error" key:
+// {"error": err.Error()}.
+//
+// The retryable flag is overridden if err wraps a RetryableError or
+// NonRetryableError, created via Retryable and NonRetryable respectively.  If err wraps
+// a StructuredError or *StructuredError, such as those created via ValidationError, the
+// error's code is added under the "code" key and its retryability is used.
+//
+// This does _not_ stop the action or workflow.
+//
+// To stop the action and prevent the workflow branch from continuing, exit
+// with a non-zero status code (ie. `os.Exit(1)`).
+//
+// To stop the action but allow workflows to continue, exit with a zero status
+// code (ie. `os.Exit(0)`)
+//
+// An error is returned if the error can't be marshalled or written, allowing the
+// caller to decide whether to exit.
+func WriteError(err error, retryable bool) error {
+	return writeOutput(func(w io.Writer) error {
+		return WriteErrorTo(w, err, retryable)
+	})
+}
+
+// WriteErrorTo writes the error to the given writer using the same format as
+// WriteError.  Unlike WriteError, any failure to marshal or write the error is
+// returned to the caller.
+func WriteErrorTo(w io.Writer, err error, retryable bool) error {
+	envelope := map[string]interface{}{"error": err.Error()}
+	var perr *PanicError
+	if errors.As(err, &perr) {
+		envelope["stack"] = perr.Stack
+	}
+	if serr, ok := asStructuredError(err); ok && serr.Code != "" {
+		envelope["code"] = serr.Code
+	}
+	return writeErrorEnvelope(w, envelope, isRetryable(err, retryable))
+}
+
+// WriteErrorChain writes the error to stdout in the same manner as WriteError, also
+// including the message of each error within the chain of wrapped errors under the
+// "causes" key:
+//
+//	{"error": "a: b: c", "causes": ["b: c", "c"], "status": 400}
+func WriteErrorChain(err error, retryable bool) error {
+	causes := []string{}
+	for cause := errors.Unwrap(err); cause != nil; cause = errors.Unwrap(cause) {
+		causes = append(causes, cause.Error())
+	}
+	return writeOutput(func(w io.Writer) error {
+		return writeErrorEnvelope(w, map[string]interface{}{
+			"error":  err.Error(),
+			"causes": causes,
+		}, isRetryable(err, retryable))
+	})
+}
+
+// StructuredError is an error containing a machine-readable code alongside its
+// message, written via WriteStructuredError.
+type StructuredError struct {
+	Message string `json:"message"`
+	Code    string `json:"code,omitempty"`
+	// Retryable indicates whether the engine may retry the step.  Non-retryable
+	// errors are treated as terminal failures.
+	Retryable bool `json:"retryable"`
+}
+
+// Error implements the error interface.
+func (e StructuredError) Error() string {
+	if e.Code == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// WriteStructuredError writes the error to stdout as a JSON object under the "error"
+// key, as opposed to the plain error string written by WriteError:
+//
+//	{"error": {"message": "...", "code": "...", "retryable": false}, "status": 400}
+//
+// As with WriteError, this does _not_ stop the action or workflow.
+func WriteStructuredError(e StructuredError) error {
+	return writeOutput(func(w io.Writer) error {
+		return writeErrorEnvelope(w, map[string]interface{}{"error": e}, e.Retryable)
+	})
+}
+
+// writeErrorEnvelope writes the given error envelope to w, adding the status and
+// whether the error is retryable.
+func writeErrorEnvelope(w io.Writer, envelope map[string]interface{}, retryable bool) error {
+	envelope["retryable"] = retryable
+	// 4xx errors are not retryable;  it indicates that the request, or input
+	// data, is wrong and simply re-running this step will not fix.
+	envelope["status"] = 400
+	if retryable {
+		// 5xx errors are retryable
+		envelope["status"] = 500
+	}
+	byt, err := encode(envelope)
+	if err != nil {
+		return fmt.Errorf("unable to marshal error: %w", err)
+	}
+
+	if _, err = fmt.Fprintln(w, string(byt)); err != nil {
+		return fmt.Errorf("unable to write error: %w", err)
+	}
+	return nil
+}
+
+// WriteResult writes the output as a JSON-encoded string to stdout, or the file descriptor
+// set via SetOutputFD.  Any data written here is captured as action output, which is added
+// to the workflow context and can be used by future actions in the workflow.
+//
+// The engine only supports a single JSON object as output, so this may only be called
+// once;  subsequent calls return ErrResultAlreadyWritten.
+//
+// Note that this does _not_ stop the action.  To stop the action, call `os.Exit(0)` or
+// return from your main function.
+func WriteResult(i *Result) error {
+	_, err := WriteResultN(i)
+	return err
+}
+
+// WriteResultN writes the result to stdout in the same manner as WriteResult,
+// returning the number of bytes written.
+func WriteResultN(i *Result) (int, error) {
+	return writeResultMeta(i, nil)
+}
+
+// writeResultMeta writes the result in the same manner as WriteResultN, including the
+// given metadata under the "_meta" key.
+func writeResultMeta(i *Result, meta map[string]interface{}) (int, error) {
+	return writeOnce(func() (n int, err error) {
+		err = writeOutput(func(w io.Writer) error {
+			n, err = writeResult(w, i, encode, resultExtra{Events: emittedEvents(), Meta: meta})
+			return err
+		})
+		return n, err
+	})
+}
+
+// WriteResultIndent writes the result to stdout in the same manner as WriteResult,
+// indenting the JSON with the given indent to make the output human readable.  This
+// is intended for local development;  WriteResult keeps output compact.  This always
+// uses the default JSON encoder, ignoring any encoder set via SetEncoder.
+func WriteResultIndent(i *Result, indent string) error {
+	_, err := writeOnce(func() (int, error) {
+		return 0, writeOutput(func(w io.Writer) error {
+			_, err := writeResult(w, i, func(v interface{}) ([]byte, error) {
+				return marshalJSONIndent(v, indent)
+			}, resultExtra{Events: emittedEvents()})
+			return err
+		})
+	})
+	return err
+}
+
+// WriteResultValidated writes the result to stdout in the same manner as WriteResult,
+// first validating the result's body against the given JSON schema.  If the body doesn't
+// match the schema nothing is written and an error describing each invalid field is
+// returned, catching changes to the shape of output before downstream steps use it.
+func WriteResultValidated(i *Result, schema []byte) error {
+	if i == nil {
+		i = &Result{Status: 201}
+	}
+	body, err := encode(i.Body)
+	if err != nil {
+		return fmt.Errorf("error writing output: %w", err)
+	}
+	if err := validateSchema(schema, body); err != nil {
+		return fmt.Errorf("invalid output: %w", err)
+	}
+	return WriteResult(&Result{Body: json.RawMessage(body), Status: i.Status})
+}
+
+// statusBody is the envelope written by WriteResultWithStatus.
+type statusBody struct {
+	Status string      `json:"status"`
+	Data   interface{} `json:"data"`
+}
+
+// WriteResultWithStatus writes the given status and data as the body of a result with a
+// 200 status, in the same manner as WriteResult.  The body is wrapped in an envelope
+// such that downstream steps can consistently branch on the status:
+//
+//	{"body": {"status": "skipped", "data": {...}}, "status": 200}
+func WriteResultWithStatus(status string, i interface{}) error {
+	return WriteResult(&Result{Body: statusBody{Status: status, Data: i}, Status: 200})
+}
+
+// WriteResultStream writes the JSON read from r as the body of a result with a 200
+// status, in the same manner as WriteResult.  The body is streamed to stdout, or the
+// file descriptor set via SetOutputFD, without being held in memory, which suits large
+// results which are already serialized such as proxied downloads.
+//
+// The body must be a single JSON value, and the result is subject to the maximum output
+// size.  As the body is validated while it's streamed, a partial result may have been
+// written if this returns an error once part of the body has been flushed.  Further
+// writes then return ErrPartialOutput rather than appending to the partial result, and
+// the action should exit via StopAndFail, which still exits with ExitFailure.  Errors
+// found before any output is flushed, eg. within small bodies, write nothing, such that
+// StopAndFail writes the error as usual.
+func WriteResultStream(r io.Reader) error {
+	_, err := writeOnce(func() (int, error) {
+		return 0, writeOutput(func(w io.Writer) error {
+			lw := &limitedWriter{w: w, max: maxOutputSize}
+			if _, err := io.WriteString(lw, `{"body":`); err != nil {
+				return err
+			}
+			if err := copyJSON(lw, r); err != nil {
+				if lw.err != nil {
+					return lw.err
+				}
+				return fmt.Errorf("error writing output: %w", err)
+			}
+			buf := &bytes.Buffer{}
+			if err := writeResultTail(buf, 200, encode, resultExtra{Events: emittedEvents()}); err != nil {
+				return fmt.Errorf("error writing output: %w", err)
+			}
+			buf.WriteString("\n")
+			_, err := lw.Write(buf.Bytes())
+			return err
+		})
+	})
+	return err
+}
+
+// copyJSON copies a single JSON value from r to w, returning an error if r doesn't
+// contain exactly one valid JSON value.
+func copyJSON(w io.Writer, r io.Reader) error {
+	dec := json.NewDecoder(io.TeeReader(r, w))
+	depth := 0
+	for n := 0; ; n++ {
+		tok, err := dec.Token()
+		if err == io.EOF && n == 0 {
+			return fmt.Errorf("result body is empty")
+		}
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return fmt.Errorf("result body is not valid json: %w", err)
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			break
+		}
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return fmt.Errorf("result body is not a single json value")
+	}
+	return nil
+}
+
+// limitedWriter writes to w, returning ErrOutputTooLarge once more than max bytes
+// have been written.  A max of zero or less disables the limit.
+type limitedWriter struct {
+	w   io.Writer
+	n   int
+	max int
+	// err records whether the limit was exceeded.
+	err error
+}
+
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	if l.max > 0 && l.n+len(p) > l.max {
+		l.err = fmt.Errorf("%w: exceeds the maximum of %d bytes", ErrOutputTooLarge, l.max)
+		return 0, l.err
+	}
+	n, err := l.w.Write(p)
+	l.n += n
+	return n, err
+}
+
+// writeOnce calls fn to write a result, ensuring that only a single result is
+// successfully written.  Events buffered via EmitEvent are discarded once written.
+func writeOnce(fn func() (int, error)) (int, error) {
+	resultMu.Lock()
+	defer resultMu.Unlock()
+
+	if resultWritten {
+		return 0, ErrResultAlreadyWritten
+	}
+	n, err := fn()
+	if err != nil {
+		return n, err
+	}
+	resultWritten = true
+	clearEmittedEvents()
+	return n, nil
+}
+
+// ResetResultState allows WriteResult to be called again, discarding any events buffered
+// via EmitEvent.  This is intended for tests which write multiple results within the same
+// process.
+func ResetResultState() {
+	resultMu.Lock()
+	defer resultMu.Unlock()
+	resultWritten = false
+	clearEmittedEvents()
+
+	bufferedMu.Lock()
+	defer bufferedMu.Unlock()
+	partialOutput = false
+}
+
+// SetOutputFD sets the file descriptor which results and errors are written to, instead
+// of stdout.  This separates the action's output from any logging written to stdout,
+// eg. by using file descriptor 3 as provided by the runner.  Setting the descriptor to 1
+// restores writing to stdout.
+//
+// This is not safe to call concurrently with writing output.
+func SetOutputFD(fd int) error {
+	if fd == 1 {
+		outputFile = nil
+		return nil
+	}
+	f, err := fdFile(fd)
+	if err != nil {
+		return fmt.Errorf("invalid output file descriptor: %w", err)
+	}
+	outputFile = f
+	return nil
+}
+
+var (
+	// fdFiles contains the file opened for each descriptor passed to SetOutputFD and
+	// SetProgressFD.  Files are never released, as an unreachable *os.File closes its
+	// descriptor when garbage collected.
+	fdFiles   = map[int]*os.File{}
+	fdFilesMu sync.Mutex
+)
+
+// fdFile returns the file for the given open file descriptor, reusing the file if the
+// descriptor was previously opened.
+func fdFile(fd int) (*os.File, error) {
+	fdFilesMu.Lock()
+	defer fdFilesMu.Unlock()
+
+	f, ok := fdFiles[fd]
+	if !ok {
+		if f = os.NewFile(uintptr(fd), fmt.Sprintf("fd%d", fd)); f == nil {
+			return nil, fmt.Errorf("%d", fd)
+		}
+		// Keep the file even if the descriptor is invalid, such that the file's finalizer
+		// can't close the descriptor once it's opened elsewhere.
+		fdFiles[fd] = f
+	}
+	if _, err := f.Stat(); err != nil {
+		return nil, fmt.Errorf("%d: %w", fd, err)
+	}
+	return f, nil
+}
+
+// output returns the writer which results and errors are written to.
+func output() io.Writer {
+	if outputFile != nil {
+		return outputFile
+	}
+	// This is resolved on each write so that stdout may be replaced, eg. in tests.
+	return os.Stdout
+}
+
+// writeOutput calls fn with a buffered writer over the output, flushing the writer once
+// fn returns.  If fn fails before any of its output is flushed the buffered output is
+// discarded;  if some of its output has already reached the output all further writes
+// are refused with ErrPartialOutput.
+func writeOutput(fn func(w io.Writer) error) error {
+	bufferedMu.Lock()
+	defer bufferedMu.Unlock()
+
+	if partialOutput {
+		return ErrPartialOutput
+	}
+
+	dest := output()
+	if buffered == nil || bufferedTo != dest {
+		if buffered != nil {
+			_ = buffered.Flush()
+		}
+		buffered = bufio.NewWriter(dest)
+		bufferedTo = dest
+	}
+
+	cw := &countingWriter{w: buffered}
+	err := fn(cw)
+	if err != nil && buffered.Buffered() == cw.n {
+		// Nothing has reached the output yet, so discard the buffered output such that
+		// the error may be written in its place.
+		buffered.Reset(dest)
+		return err
+	}
+	if ferr := buffered.Flush(); ferr != nil && err == nil {
+		err = fmt.Errorf("unable to flush output: %w", ferr)
+	}
+	if err != nil && cw.n > 0 {
+		partialOutput = true
+	}
+	return err
+}
+
+// countingWriter writes to w, counting the bytes written.
+type countingWriter struct {
+	w io.Writer
+	n int
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += n
+	return n, err
+}
+
+// FlushOutput flushes any buffered output to stdout, or the file descriptor set via
+// SetOutputFD.  Results and errors are flushed once written, so this is only necessary
+// for callers which manage their own writes;  StopAndContinue and StopAndFail flush
+// output prior to exiting.
+func FlushOutput() error {
+	bufferedMu.Lock()
+	defer bufferedMu.Unlock()
+	if buffered == nil {
+		return nil
+	}
+	if err := buffered.Flush(); err != nil {
+		return fmt.Errorf("unable to flush output: %w", err)
+	}
+	return nil
+}
+
+// SetEncoder sets the function used to marshal results and errors, allowing custom
+// JSON encoders to be used.  By default output is encoded as JSON without escaping
+// HTML characters, so that values such as URLs are written verbatim.  Passing nil
+// restores the default encoder.
+//
+// This is not safe to call concurrently with writing output.
+func SetEncoder(fn func(interface{}) ([]byte, error)) {
+	if fn == nil {
+		fn = marshalJSON
+	}
+	encode = fn
+}
+
+func marshalJSON(v interface{}) ([]byte, error) {
+	return marshalJSONIndent(v, "")
+}
+
+func marshalJSONIndent(v interface{}, indent string) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", indent)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	// Encode terminates each value with a newline, which is added when writing.
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// SetMaxOutputSize sets the maximum size of a marshalled result, in bytes.  Results
+// which exceed this size return ErrOutputTooLarge instead of being written, as the
+// engine rejects oversized outputs.  A size of zero or less disables the limit.
+//
+// This is not safe to call concurrently with writing results.
+func SetMaxOutputSize(n int) {
+	maxOutputSize = n
+}
+
+// WriteResultTo writes the result as a JSON-encoded string to the given writer.  This
+// is equivalent to WriteResult without writing to stdout, and is useful for testing
+// or redirecting output.
+func WriteResultTo(w io.Writer, i *Result) error {
+	_, err := writeResult(w, i, encode, resultExtra{})
+	return err
+}
+
+// resultExtra contains the fields written alongside a result's body and status.
+type resultExtra struct {
+	// Events contains the events buffered via EmitEvent.
+	Events []Event `json:"events,omitempty"`
+	// Meta contains metadata about the step recorded by Run, such as its duration.
+	Meta map[string]interface{} `json:"_meta,omitempty"`
+}
+
+func (e resultExtra) empty() bool {
+	return len(e.Events) == 0 && len(e.Meta) == 0
+}
+
+// writeResult writes the result to w, including the given extra fields.
+func writeResult(w io.Writer, i *Result, marshal func(interface{}) ([]byte, error), extra resultExtra) (int, error) {
+	if i == nil {
+		if extra.empty() {
+			return fmt.Fprint(w, `{"body": null, "status": 201}`)
+		}
+		i = &Result{Status: 201}
+	}
+
+	byt, err := marshalResult(i, marshal, extra)
+	if err != nil {
+		return 0, fmt.Errorf("error writing output: %w", err)
+	}
+	if maxOutputSize > 0 && len(byt) > maxOutputSize {
+		return 0, fmt.Errorf("%w: %d bytes exceeds the maximum of %d bytes", ErrOutputTooLarge, len(byt), maxOutputSize)
+	}
+
+	return fmt.Fprintln(w, string(byt))
+}
+
+// marshalResult marshals the result, adding any extra fields such as emitted events.
+// Bodies which are already JSON, either as a json.RawMessage or a []byte containing valid
+// JSON, are written verbatim rather than being marshalled again.  Other []byte bodies are
+// marshalled as base64 strings.
+func marshalResult(i *Result, marshal func(interface{}) ([]byte, error), extra resultExtra) ([]byte, error) {
+	var raw []byte
+	switch body := i.Body.(type) {
+	case json.RawMessage:
+		if !json.Valid(body) {
+			return nil, fmt.Errorf("result body is not valid json")
+		}
+		raw = body
+	case []byte:
+		if json.Valid(body) {
+			raw = body
+		}
+	}
+	if raw == nil {
+		if extra.empty() {
+			return marshal(i)
+		}
+		return marshal(struct {
+			*Result
+			resultExtra
+		}{Result: i, resultExtra: extra})
+	}
+
+	buf := &bytes.Buffer{}
+	buf.WriteString(`{"body":`)
+	buf.Write(raw)
+	if err := writeResultTail(buf, i.Status, marshal, extra); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeResultTail writes the fields of a result following its body to buf, closing the
+// result's object.
+func writeResultTail(buf *bytes.Buffer, status int, marshal func(interface{}) ([]byte, error), extra resultExtra) error {
+	buf.WriteString(`,"status":`)
+	buf.WriteString(strconv.Itoa(status))
+	for _, f := range []struct {
+		key   string
+		value interface{}
+		ok    bool
+	}{
+		{"events", extra.Events, len(extra.Events) > 0},
+		{"_meta", extra.Meta, len(extra.Meta) > 0},
+	} {
+		if !f.ok {
+			continue
+		}
+		byt, err := marshal(f.value)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(`,"` + f.key + `":`)
+		buf.Write(byt)
+	}
+	buf.WriteString(`}`)
+	return nil
+}
+
+// OutputBuilder accumulates fields of a result's body so that actions may build
+// their output incrementally, writing a single result once complete.  The zero
+// value is ready to use, and writes a status of 200 unless SetStatus is called.
+//
+// OutputBuilder is not safe for concurrent use.
+type OutputBuilder struct {
+	body   map[string]interface{}
+	status int
+}
+
+// Set sets the given key within the result's body, overwriting any previous value.
+func (o *OutputBuilder) Set(key string, value interface{}) {
+	if o.body == nil {
+		o.body = map[string]interface{}{}
+	}
+	o.body[key] = value
+}
+
+// SetStatus sets the status of the result.
+func (o *OutputBuilder) SetStatus(status int) {
+	o.status = status
+}
+
+// Write writes the accumulated fields as a single result via WriteResult.  An empty
+// builder writes an empty object as the result's body.
+func (o *OutputBuilder) Write() error {
+	body := o.body
+	if body == nil {
+		body = map[string]interface{}{}
+	}
+	status := o.status
+	if status == 0 {
+		status = 200
+	}
+	return WriteResult(&Result{Body: body, Status: status})
+}
@@ -0,0 +1,352 @@
+package actionsdk
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// captureStdout calls fn, returning everything written to stdout during the call.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	f, err := os.CreateTemp(t.TempDir(), "stdout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	stdout := os.Stdout
+	os.Stdout = f
+	defer func() { os.Stdout = stdout }()
+	fn()
+
+	byt, err := os.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(byt)
+}
+
+func TestOutputSizeLimit(t *testing.T) {
+	defer SetMaxOutputSize(DefaultMaxOutputSize)
+
+	orders := make([]order, 200000)
+	for n := range orders {
+		orders[n] = order{ID: fmt.Sprintf("o_%d", n), Total: float64(n)}
+	}
+
+	tests := []struct {
+		name    string
+		body    interface{}
+		max     int
+		wantErr bool
+	}{
+		{name: "within limit", body: strings.Repeat("x", 100), max: 1024},
+		{name: "exceeds limit", body: strings.Repeat("x", 100), max: 64, wantErr: true},
+		{name: "disabled", body: strings.Repeat("x", 100), max: 0},
+		{name: "large slice", body: orders, max: DefaultMaxOutputSize, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetMaxOutputSize(tt.max)
+			result := &Result{Body: tt.body, Status: 200}
+			byt, err := marshalJSON(result)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			buf := &bytes.Buffer{}
+			err = WriteResultTo(buf, result)
+			if tt.wantErr {
+				if !errors.Is(err, ErrOutputTooLarge) {
+					t.Fatalf("expected ErrOutputTooLarge, got %v", err)
+				}
+				want := fmt.Sprintf("%d bytes exceeds the maximum of %d bytes", len(byt), tt.max)
+				if !strings.Contains(err.Error(), want) {
+					t.Fatalf("expected the error to contain %q, got %q", want, err.Error())
+				}
+				if buf.Len() != 0 {
+					t.Fatalf("expected nothing to be written, got %q", truncate(buf.String()))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != string(byt)+"\n" {
+				t.Fatalf("expected the result to be written, got %q", truncate(buf.String()))
+			}
+		})
+	}
+}
+
+func TestWriteErrorChain(t *testing.T) {
+	root := errors.New("connection refused")
+	mid := fmt.Errorf("fetching order: %w", root)
+	top := fmt.Errorf("charging card: %w", Retryable(mid))
+
+	tests := []struct {
+		name          string
+		err           error
+		retryable     bool
+		wantCauses    []string
+		wantRetryable bool
+	}{
+		{
+			name:          "three levels",
+			err:           fmt.Errorf("charging card: %w", mid),
+			wantCauses:    []string{"fetching order: connection refused", "connection refused"},
+			wantRetryable: false,
+		},
+		{
+			name:          "retryable wrapper",
+			err:           top,
+			wantCauses:    []string{"fetching order: connection refused", "fetching order: connection refused", "connection refused"},
+			wantRetryable: true,
+		},
+		{
+			name:          "unwrapped",
+			err:           root,
+			retryable:     true,
+			wantCauses:    []string{},
+			wantRetryable: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			out := captureStdout(t, func() {
+				err = WriteErrorChain(tt.err, tt.retryable)
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := struct {
+				Error     string   `json:"error"`
+				Causes    []string `json:"causes"`
+				Retryable bool     `json:"retryable"`
+				Status    int      `json:"status"`
+			}{}
+			if err := json.Unmarshal([]byte(out), &got); err != nil {
+				t.Fatalf("invalid output %q: %v", out, err)
+			}
+			if got.Error != tt.err.Error() {
+				t.Fatalf("expected error %q, got %q", tt.err.Error(), got.Error)
+			}
+			if !reflect.DeepEqual(got.Causes, tt.wantCauses) {
+				t.Fatalf("expected causes %q, got %q", tt.wantCauses, got.Causes)
+			}
+			if got.Retryable != tt.wantRetryable || (got.Status == 500) != tt.wantRetryable {
+				t.Fatalf("expected retryable %v, got %v with status %d", tt.wantRetryable, got.Retryable, got.Status)
+			}
+		})
+	}
+}
+
+func TestWriteResultRawBody(t *testing.T) {
+	// Whitespace and key order differ from the encoder's output, so would change if the
+	// body were marshalled again.
+	raw := `{ "b": 2,  "a": [1, 2.50] }`
+
+	tests := []struct {
+		name    string
+		body    interface{}
+		want    string
+		wantErr bool
+	}{
+		{name: "raw message", body: json.RawMessage(raw), want: `{"body":` + raw + `,"status":200}` + "\n"},
+		{name: "json bytes", body: []byte(raw), want: `{"body":` + raw + `,"status":200}` + "\n"},
+		{name: "non-json bytes", body: []byte("hi"), want: `{"body":"aGk=","status":200}` + "\n"},
+		{name: "invalid raw message", body: json.RawMessage(`{"a":`), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			err := WriteResultTo(buf, &Result{Body: tt.body, Status: 200})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, buf.String())
+			}
+		})
+	}
+}
+
+func TestWriteResultValidated(t *testing.T) {
+	schema := []byte(`{"type":"object","required":["id"],"properties":{"id":{"type":"string"}}}`)
+
+	tests := []struct {
+		name    string
+		body    interface{}
+		wantErr string
+	}{
+		{name: "valid", body: map[string]interface{}{"id": "r_123"}},
+		{name: "valid raw body", body: json.RawMessage(`{"id":"r_123"}`)},
+		{name: "missing field", body: map[string]interface{}{}, wantErr: "id: is required"},
+		{name: "type mismatch", body: map[string]interface{}{"id": 123}, wantErr: "id: expected string, got number"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer ResetResultState()
+
+			var err error
+			out := captureStdout(t, func() {
+				err = WriteResultValidated(&Result{Body: tt.body, Status: 200}, schema)
+			})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if out != `{"body":{"id":"r_123"},"status":200}`+"\n" {
+					t.Fatalf("unexpected output %q", out)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+			}
+			if out != "" {
+				t.Fatalf("expected nothing to be written, got %q", out)
+			}
+
+			// Invalid output isn't recorded as the result, so a valid result may follow.
+			captureStdout(t, func() {
+				err = WriteResult(&Result{Body: "fallback", Status: 200})
+			})
+			if err != nil {
+				t.Fatalf("expected a result to be writable after invalid output, got %v", err)
+			}
+		})
+	}
+}
+
+func TestWriteResultStream(t *testing.T) {
+	defer SetMaxOutputSize(DefaultMaxOutputSize)
+
+	// large exceeds the output's buffer, so is flushed before the body is found invalid.
+	large := `"` + strings.Repeat("x", 8192)
+
+	tests := []struct {
+		name        string
+		body        string
+		max         int
+		want        string
+		wantErr     error
+		wantPartial bool
+	}{
+		{name: "valid", body: `{"id": 1}`, max: 64, want: `{"body":{"id": 1},"status":200}` + "\n"},
+		{name: "invalid json", body: `{"id": 1,`, max: 64},
+		{name: "trailing data", body: `{"id": 1} {}`, max: 64},
+		{name: "too large", body: `"` + strings.Repeat("x", 100) + `"`, max: 64, wantErr: ErrOutputTooLarge},
+		{name: "invalid after flushing", body: large, wantPartial: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetMaxOutputSize(tt.max)
+			defer ResetResultState()
+
+			var err error
+			out := captureStdout(t, func() {
+				err = WriteResultStream(strings.NewReader(tt.body))
+			})
+			if tt.want != "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if out != tt.want {
+					t.Fatalf("expected %q, got %q", tt.want, out)
+				}
+				return
+			}
+			if err == nil || (tt.wantErr != nil && !errors.Is(err, tt.wantErr)) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+
+			if tt.wantPartial {
+				if !strings.HasPrefix(out, `{"body":"xxx`) {
+					t.Fatalf("expected the flushed output to be written, got %q", truncate(out))
+				}
+				// Nothing may be appended to the partial result, including the error.
+				out = captureStdout(t, func() {
+					err = WriteError(errors.New("stream failed"), false)
+				})
+				if !errors.Is(err, ErrPartialOutput) || out != "" {
+					t.Fatalf("expected ErrPartialOutput with no output, got %v and %q", err, out)
+				}
+				return
+			}
+
+			// Nothing was flushed, so the error is written in place of the result.
+			if out != "" {
+				t.Fatalf("expected nothing to be written, got %q", out)
+			}
+			out = captureStdout(t, func() {
+				err = WriteError(errors.New("stream failed"), false)
+			})
+			if err != nil || !strings.HasPrefix(out, `{"error":"stream failed"`) {
+				t.Fatalf("expected the error to be written, got %v and %q", err, out)
+			}
+		})
+	}
+}
+
+// truncate shortens s for inclusion within test failures.
+func truncate(s string) string {
+	if len(s) > 64 {
+		return s[:64] + "..."
+	}
+	return s
+}
+
+func TestWriteResultTo(t *testing.T) {
+	tests := []struct {
+		name   string
+		result *Result
+		want   string
+	}{
+		{
+			name:   "nil",
+			result: nil,
+			want:   `{"body": null, "status": 201}`,
+		},
+		{
+			name:   "struct",
+			result: &Result{Body: receipt{OrderID: "o_123"}, Status: 200},
+			want:   `{"body":{"order_id":"o_123"},"status":200}` + "\n",
+		},
+		{
+			name:   "slice",
+			result: &Result{Body: []int{1, 2, 3}, Status: 200},
+			want:   `{"body":[1,2,3],"status":200}` + "\n",
+		},
+		{
+			name:   "map",
+			result: &Result{Body: map[string]interface{}{"url": "https://a?b=1&c=2", "ok": true}, Status: 202},
+			want:   `{"body":{"ok":true,"url":"https://a?b=1&c=2"},"status":202}` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			if err := WriteResultTo(buf, tt.result); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package actionsdk
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sync"
+	"time"
+)
+
+var (
+	// progressFile is the file progress records are written to, if set via
+	// SetProgressFD.
+	progressFile *os.File
+	progressMu   sync.Mutex
+)
+
+// progressRecord is a single newline-delimited record written to the progress stream.
+type progressRecord struct {
+	Type    string   `json:"type"`
+	Percent *float64 `json:"pct,omitempty"`
+	Message string   `json:"message,omitempty"`
+	// Timestamp is the time the record was written, in Unix milliseconds.
+	Timestamp int64 `json:"ts"`
+}
+
+// Heartbeat signals that the action is still running, such that the engine doesn't
+// consider long-running actions stalled.  Heartbeats are written as newline-delimited
+// JSON records to the progress stream, which is stderr unless set via SetProgressFD:
+//
+//	{"type":"heartbeat","ts":1700000000000}
+//
+// Progress records never affect the action's result, which is written separately via
+// WriteResult.
+func Heartbeat() error {
+	return writeProgress(progressRecord{Type: "heartbeat"})
+}
+
+// Progress reports the completion of the action as a percentage between 0 and 100,
+// alongside an optional message.  Progress is written to the progress stream in the
+// same manner as Heartbeat:
+//
+//	{"type":"progress","pct":50,"message":"processed 500 rows","ts":1700000000000}
+func Progress(pct float64, message string) error {
+	if math.IsNaN(pct) || pct < 0 || pct > 100 {
+		return fmt.Errorf("invalid progress percentage: %v", pct)
+	}
+	return writeProgress(progressRecord{Type: "progress", Percent: &pct, Message: message})
+}
+
+// SetProgressFD sets the file descriptor which progress records are written to, instead
+// of stderr.  Setting the descriptor to 2 restores writing to stderr.
+//
+// This is not safe to call concurrently with writing progress.
+func SetProgressFD(fd int) error {
+	if fd == 2 {
+		progressFile = nil
+		return nil
+	}
+	f, err := fdFile(fd)
+	if err != nil {
+		return fmt.Errorf("invalid progress file descriptor: %w", err)
+	}
+	progressFile = f
+	return nil
+}
+
+// progressOutput returns the writer which progress records are written to.
+func progressOutput() io.Writer {
+	if progressFile != nil {
+		return progressFile
+	}
+	return os.Stderr
+}
+
+func writeProgress(r progressRecord) error {
+	r.Timestamp = time.Now().UnixMilli()
+	byt, err := marshalJSON(r)
+	if err != nil {
+		return fmt.Errorf("unable to marshal progress: %w", err)
+	}
+
+	progressMu.Lock()
+	defer progressMu.Unlock()
+	// Write each record in a single call, so that records aren't interleaved.
+	if _, err := progressOutput().Write(append(byt, '\n')); err != nil {
+		return fmt.Errorf("unable to write progress: %w", err)
+	}
+	return nil
+}
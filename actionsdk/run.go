@@ -0,0 +1,227 @@
+package actionsdk
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"runtime/debug"
+	"time"
+)
+
+var (
+	// ErrStdinClosed is the cause of a handler's context being cancelled when stdin is
+	// closed, as returned by context.Cause.
+	ErrStdinClosed = errors.New("stdin closed")
+)
+
+// Handler is the function invoked by Run with the event which triggered the function.
+// The returned value is written as the action's result.
+type Handler func(ctx context.Context, e Event) (interface{}, error)
+
+// RunOption configures Run.
+type RunOption func(*runConfig)
+
+type runConfig struct {
+	watchStdin bool
+	middleware []Middleware
+	duration   bool
+}
+
+// WithDuration includes the duration of the handler, in milliseconds, within the result
+// under the reserved "_meta" key:
+//
+//	{"body": {...}, "status": 200, "_meta": {"duration_ms": 42}}
+//
+// This is opt-in so that results consumed by strict downstream schemas are unchanged.
+func WithDuration() RunOption {
+	return func(c *runConfig) {
+		c.duration = true
+	}
+}
+
+// Middleware wraps a handler, allowing behaviour such as logging or metrics to be added
+// around every invocation.  Middleware may inspect the event and the handler's result or
+// error, or return early without calling next.
+type Middleware func(next Handler) Handler
+
+// WithMiddleware adds middleware around the handler invoked by Run.  Middleware runs in
+// the order given, such that the first middleware is the outermost:
+//
+//	actionsdk.Run(handler, actionsdk.WithMiddleware(actionsdk.Timing(), authorize))
+//
+// Here Timing observes the duration of both authorize and the handler.
+func WithMiddleware(m ...Middleware) RunOption {
+	return func(c *runConfig) {
+		c.middleware = append(c.middleware, m...)
+	}
+}
+
+// Timing returns middleware which logs the duration of each invocation of the handler
+// via the logger set with SetLogger.
+func Timing() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, e Event) (interface{}, error) {
+			start := time.Now()
+			out, err := next(ctx, e)
+			logger.Info("handler finished", "event", e.Name, "duration", time.Since(start), "error", err)
+			return out, err
+		}
+	}
+}
+
+// WithStdinWatch cancels the handler's context once stdin closes, with ErrStdinClosed as
+// the cause, for runners which cancel a step by closing its stdin pipe or socket.  This
+// reads and discards stdin, and has no effect if the args were read from stdin or stdin
+// isn't a pipe or socket.
+//
+// This is opt-in as one-shot actions are commonly launched with stdin already closed,
+// which would otherwise cancel the handler's context immediately.
+func WithStdinWatch() RunOption {
+	return func(c *runConfig) {
+		c.watchStdin = true
+	}
+}
+
+// Run wires up the lifecycle of an action:  it loads the args, invokes the handler with
+// the triggering event, and writes the handler's return value via WriteResult.  If the
+// handler returns a *Result it's written as-is;  any other value is written as the body
+// of a result with a 200 status.
+//
+// The handler's context carries the action's args, which may be read via ArgsFromContext,
+// and is cancelled once the step's Deadline passes, or once stdin closes if enabled via
+// WithStdinWatch.
+//
+// If the args can't be loaded or the handler returns an error or panics, the error is
+// written via WriteError and the action exits with ExitFailure.  Errors returned by the
+// handler are retryable by default, as most failures such as network errors and timeouts
+// are transient;  permanent failures should be returned via NonRetryable or as a
+// non-retryable StructuredError, such as those created via ValidationError.  Errors
+// loading the args are never retried.  This allows the main function of most actions to
+// be a single call:
+//
+//	func main() {
+//		actionsdk.Run(func(ctx context.Context, e actionsdk.Event) (interface{}, error) {
+//			return map[string]string{"message": "hello " + e.Name}, nil
+//		})
+//	}
+func Run(fn Handler, opts ...RunOption) {
+	c := &runConfig{}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	args, err := GetArgs()
+	if err != nil {
+		StopAndFail(err)
+		return
+	}
+	evt, err := args.GetEvent()
+	if err != nil {
+		StopAndFail(err)
+		return
+	}
+
+	ctx := WithArgs(traceContext(context.Background(), args), args)
+	if deadline, ok := args.Deadline(); ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+		defer cancel()
+	}
+	if c.watchStdin && !argsFromStdin && stdinStream() {
+		var cancel context.CancelCauseFunc
+		ctx, cancel = context.WithCancelCause(ctx)
+		defer cancel(nil)
+		go func() {
+			_, _ = io.Copy(io.Discard, os.Stdin)
+			cancel(ErrStdinClosed)
+		}()
+	}
+
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		fn = c.middleware[i](fn)
+	}
+	start := time.Now()
+	out, err := invoke(ctx, fn, evt)
+	elapsed := time.Since(start)
+	if err != nil {
+		StopAndRetry(err)
+		return
+	}
+
+	var meta map[string]interface{}
+	if c.duration {
+		meta = map[string]interface{}{"duration_ms": elapsed.Milliseconds()}
+	}
+	if _, err := writeResultMeta(toResult(out), meta); err != nil {
+		StopAndFail(err)
+	}
+}
+
+// RunTyped runs a typed handler in the same manner as Run, decoding the triggering
+// event's data into In and writing the handler's Out as the result.  If the event's data
+// can't be decoded into In, the error is written and the action exits with ExitFailure.
+//
+//	actionsdk.RunTyped(func(ctx context.Context, order OrderPlaced) (Receipt, error) {
+//		return sendReceipt(ctx, order)
+//	})
+func RunTyped[In, Out any](fn func(ctx context.Context, in In) (Out, error), opts ...RunOption) {
+	Run(func(ctx context.Context, e Event) (interface{}, error) {
+		in, err := EventData[In](e)
+		if err != nil {
+			return nil, NonRetryable(err)
+		}
+		out, err := fn(ctx, in)
+		if err != nil {
+			return nil, err
+		}
+		return out, nil
+	}, opts...)
+}
+
+// Recover recovers from a panic, writing the panic and its stack trace as an error and
+// exiting with ExitFailure.  This must be deferred directly:
+//
+//	func main() {
+//		defer actionsdk.Recover()
+//		...
+//	}
+//
+// Without this, panics crash the action with a stack trace on stderr, which the engine
+// can't parse as the action's error.
+func Recover() {
+	if r := recover(); r != nil {
+		StopAndFail(&PanicError{Value: r, Stack: string(debug.Stack())})
+	}
+}
+
+// stdinStream returns whether stdin is a pipe or socket, which may be closed while the
+// action runs.
+func stdinStream() bool {
+	fi, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&(os.ModeNamedPipe|os.ModeSocket) != 0
+}
+
+// invoke calls the handler, converting any panic into a *PanicError.
+func invoke(ctx context.Context, fn Handler, evt Event) (out interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: string(debug.Stack())}
+		}
+	}()
+	return fn(ctx, evt)
+}
+
+// toResult converts a handler's return value into a Result.
+func toResult(out interface{}) *Result {
+	switch r := out.(type) {
+	case *Result:
+		return r
+	case Result:
+		return &r
+	}
+	return &Result{Body: out, Status: 200}
+}
@@ -0,0 +1,175 @@
+package actionsdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// runAction calls fn with the given args, returning the output written and the exit
+// code, or -1 if fn didn't exit.
+func runAction(t *testing.T, a *Args, fn func()) (map[string]interface{}, int) {
+	t.Helper()
+	SetArgs(a)
+	code := -1
+	prev := exit
+	exit = func(c int) { code = c }
+	defer func() {
+		exit = prev
+		ResetArgs()
+		ResetResultState()
+	}()
+
+	out := captureStdout(t, fn)
+	result := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("invalid output %q: %v", out, err)
+	}
+	return result, code
+}
+
+type order struct {
+	ID    string  `json:"id"`
+	Total float64 `json:"total"`
+}
+
+type receipt struct {
+	OrderID string `json:"order_id"`
+}
+
+func TestRunTyped(t *testing.T) {
+	tests := []struct {
+		name          string
+		data          map[string]interface{}
+		err           error
+		wantCode      int
+		wantBody      map[string]interface{}
+		wantRetryable bool
+	}{
+		{
+			name:     "success",
+			data:     map[string]interface{}{"id": "o_123", "total": 10},
+			wantCode: -1,
+			wantBody: map[string]interface{}{"order_id": "o_123"},
+		},
+		{
+			name:          "invalid event data",
+			data:          map[string]interface{}{"id": 123},
+			wantCode:      ExitFailure,
+			wantRetryable: false,
+		},
+		{
+			name:          "handler error",
+			data:          map[string]interface{}{"id": "o_123"},
+			err:           errors.New("payment gateway unavailable"),
+			wantCode:      ExitFailure,
+			wantRetryable: true,
+		},
+		{
+			name:          "non-retryable handler error",
+			data:          map[string]interface{}{"id": "o_123"},
+			err:           NonRetryable(errors.New("card declined")),
+			wantCode:      ExitFailure,
+			wantRetryable: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &Args{Event: Event{Name: "order/placed", Data: tt.data}}
+			out, code := runAction(t, a, func() {
+				RunTyped(func(ctx context.Context, o order) (receipt, error) {
+					return receipt{OrderID: o.ID}, tt.err
+				})
+			})
+			if code != tt.wantCode {
+				t.Fatalf("expected exit code %d, got %d", tt.wantCode, code)
+			}
+			if tt.wantBody != nil {
+				body, _ := out["body"].(map[string]interface{})
+				if body["order_id"] != tt.wantBody["order_id"] || out["status"] != float64(200) {
+					t.Fatalf("unexpected result: %v", out)
+				}
+				return
+			}
+			if out["error"] == nil || out["retryable"] != tt.wantRetryable {
+				t.Fatalf("expected an error with retryable %v, got %v", tt.wantRetryable, out)
+			}
+		})
+	}
+}
+
+func TestRunMiddlewareOrder(t *testing.T) {
+	calls := []string{}
+	record := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, e Event) (interface{}, error) {
+				calls = append(calls, name+" before")
+				out, err := next(ctx, e)
+				calls = append(calls, name+" after")
+				return out, err
+			}
+		}
+	}
+	handler := func(ctx context.Context, e Event) (interface{}, error) {
+		calls = append(calls, "handler")
+		return "ok", nil
+	}
+
+	tests := []struct {
+		name string
+		opts []RunOption
+		want []string
+	}{
+		{
+			name: "none",
+			want: []string{"handler"},
+		},
+		{
+			name: "single option",
+			opts: []RunOption{WithMiddleware(record("a"), record("b"))},
+			want: []string{"a before", "b before", "handler", "b after", "a after"},
+		},
+		{
+			name: "multiple options",
+			opts: []RunOption{WithMiddleware(record("a")), WithMiddleware(record("b"))},
+			want: []string{"a before", "b before", "handler", "b after", "a after"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls = []string{}
+			out, code := runAction(t, &Args{Event: Event{Name: "order/placed"}}, func() {
+				Run(handler, tt.opts...)
+			})
+			if code != -1 || out["body"] != "ok" {
+				t.Fatalf("unexpected result %v with exit code %d", out, code)
+			}
+			if !reflect.DeepEqual(calls, tt.want) {
+				t.Fatalf("expected calls %q, got %q", tt.want, calls)
+			}
+		})
+	}
+}
+
+func TestRunMiddlewareShortCircuit(t *testing.T) {
+	called := false
+	deny := func(next Handler) Handler {
+		return func(ctx context.Context, e Event) (interface{}, error) {
+			return nil, NonRetryable(errors.New("unauthorized"))
+		}
+	}
+	out, code := runAction(t, &Args{Event: Event{Name: "order/placed"}}, func() {
+		Run(func(ctx context.Context, e Event) (interface{}, error) {
+			called = true
+			return nil, nil
+		}, WithMiddleware(deny))
+	})
+	if called {
+		t.Fatal("expected the handler not to be called")
+	}
+	if code != ExitFailure || out["error"] != "unauthorized" {
+		t.Fatalf("unexpected result %v with exit code %d", out, code)
+	}
+}
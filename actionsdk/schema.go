@@ -0,0 +1,235 @@
+package actionsdk
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// schema is the subset of JSON Schema used to validate config and output.  This
+// supports the structural keywords used within action definitions:  type, properties,
+// required, additionalProperties, items, enum, minimum, maximum, minLength, maxLength,
+// pattern, minItems and maxItems.  Unsupported keywords are ignored.
+type schema struct {
+	Type                 schemaTypes        `json:"type"`
+	Properties           map[string]*schema `json:"properties"`
+	Required             []string           `json:"required"`
+	AdditionalProperties *schema            `json:"additionalProperties"`
+	Items                *schema            `json:"items"`
+	Enum                 []interface{}      `json:"enum"`
+	Minimum              *float64           `json:"minimum"`
+	Maximum              *float64           `json:"maximum"`
+	MinLength            *int               `json:"minLength"`
+	MaxLength            *int               `json:"maxLength"`
+	Pattern              string             `json:"pattern"`
+	MinItems             *int               `json:"minItems"`
+	MaxItems             *int               `json:"maxItems"`
+
+	// reject is set for the boolean schema false, which no value matches.
+	reject bool
+}
+
+func (s *schema) UnmarshalJSON(byt []byte) error {
+	var b bool
+	if err := json.Unmarshal(byt, &b); err == nil {
+		*s = schema{reject: !b}
+		return nil
+	}
+	// Use an alias to prevent recursing into this method.
+	type alias schema
+	return json.Unmarshal(byt, (*alias)(s))
+}
+
+// schemaTypes is the "type" keyword, which may be a single type or a list of types.
+type schemaTypes []string
+
+func (t *schemaTypes) UnmarshalJSON(byt []byte) error {
+	var single string
+	if err := json.Unmarshal(byt, &single); err == nil {
+		*t = schemaTypes{single}
+		return nil
+	}
+	return json.Unmarshal(byt, (*[]string)(t))
+}
+
+// validateSchema validates the JSON document against the JSON schema, returning an error
+// describing every field which doesn't match.
+func validateSchema(schemaJSON, doc []byte) error {
+	s := &schema{}
+	if err := json.Unmarshal(schemaJSON, s); err != nil {
+		return fmt.Errorf("invalid schema: %w", err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	// Numbers are kept as json.Number so that integers can be distinguished.
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return fmt.Errorf("invalid json: %w", err)
+	}
+
+	problems := []string{}
+	if err := s.validate("", v, &problems); err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("schema validation failed: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func (s *schema) validate(path string, v interface{}, problems *[]string) error {
+	fail := func(format string, args ...interface{}) {
+		field := path
+		if field == "" {
+			field = "(root)"
+		}
+		*problems = append(*problems, field+": "+fmt.Sprintf(format, args...))
+	}
+
+	if s.reject {
+		fail("is not allowed")
+		return nil
+	}
+
+	typ := jsonType(v)
+	if len(s.Type) > 0 && !s.allowsType(typ, v) {
+		fail("expected %s, got %s", strings.Join(s.Type, " or "), typ)
+		return nil
+	}
+
+	if len(s.Enum) > 0 && !inEnum(s.Enum, v) {
+		fail("must be one of the enumerated values")
+	}
+
+	switch val := v.(type) {
+	case json.Number:
+		f, _ := val.Float64()
+		if s.Minimum != nil && f < *s.Minimum {
+			fail("must be >= %v", *s.Minimum)
+		}
+		if s.Maximum != nil && f > *s.Maximum {
+			fail("must be <= %v", *s.Maximum)
+		}
+	case string:
+		n := len([]rune(val))
+		if s.MinLength != nil && n < *s.MinLength {
+			fail("must be at least %d characters", *s.MinLength)
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			fail("must be at most %d characters", *s.MaxLength)
+		}
+		if s.Pattern != "" {
+			re, err := regexp.Compile(s.Pattern)
+			if err != nil {
+				return fmt.Errorf("invalid schema pattern %q: %w", s.Pattern, err)
+			}
+			if !re.MatchString(val) {
+				fail("must match pattern %q", s.Pattern)
+			}
+		}
+	case []interface{}:
+		if s.MinItems != nil && len(val) < *s.MinItems {
+			fail("must contain at least %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(val) > *s.MaxItems {
+			fail("must contain at most %d items", *s.MaxItems)
+		}
+		if s.Items != nil {
+			for n, item := range val {
+				if err := s.Items.validate(fmt.Sprintf("%s[%d]", path, n), item, problems); err != nil {
+					return err
+				}
+			}
+		}
+	case map[string]interface{}:
+		for _, key := range s.Required {
+			if _, ok := val[key]; !ok {
+				*problems = append(*problems, joinPath(path, key)+": is required")
+			}
+		}
+
+		// Iterate in a deterministic order so that errors are stable.
+		keys := make([]string, 0, len(val))
+		for key := range val {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			prop, ok := s.Properties[key]
+			if !ok {
+				prop = s.AdditionalProperties
+			}
+			if prop == nil {
+				continue
+			}
+			if err := prop.validate(joinPath(path, key), val[key], problems); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// allowsType returns whether the schema's type keyword permits the given JSON type.
+func (s *schema) allowsType(typ string, v interface{}) bool {
+	for _, t := range s.Type {
+		if t == typ {
+			return true
+		}
+		if t == "integer" && typ == "number" {
+			f, _ := v.(json.Number).Float64()
+			if f == math.Trunc(f) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// jsonType returns the JSON schema type name of the decoded value.
+func jsonType(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+func inEnum(enum []interface{}, v interface{}) bool {
+	for _, e := range enum {
+		// Enum values are decoded as float64, whereas documents use json.Number.
+		if n, ok := v.(json.Number); ok {
+			f, _ := n.Float64()
+			if e == f {
+				return true
+			}
+			continue
+		}
+		if reflect.DeepEqual(e, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,77 @@
+package actionsdk
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateSchema(t *testing.T) {
+	schema := `{
+		"type": "object",
+		"required": ["name", "limit"],
+		"properties": {
+			"name": {"type": "string", "minLength": 1, "pattern": "^[a-z]+$"},
+			"limit": {"type": "integer", "minimum": 1, "maximum": 100},
+			"mode": {"enum": ["fast", "safe"]},
+			"tags": {"type": "array", "items": {"type": "string"}, "maxItems": 2}
+		},
+		"additionalProperties": false
+	}`
+
+	tests := []struct {
+		name string
+		doc  string
+		// want contains the problems reported, or is empty if the document is valid.
+		want []string
+	}{
+		{name: "valid", doc: `{"name":"orders","limit":10,"mode":"fast","tags":["a"]}`},
+		{name: "missing required field", doc: `{"name":"orders"}`, want: []string{"limit: is required"}},
+		{name: "type mismatch", doc: `{"name":"orders","limit":"10"}`, want: []string{"limit: expected integer, got string"}},
+		{name: "non-integer", doc: `{"name":"orders","limit":1.5}`, want: []string{"limit: expected integer, got number"}},
+		{name: "out of range", doc: `{"name":"orders","limit":500}`, want: []string{"limit: must be <= 100"}},
+		{name: "pattern", doc: `{"name":"Orders","limit":1}`, want: []string{`name: must match pattern "^[a-z]+$"`}},
+		{name: "enum", doc: `{"name":"orders","limit":1,"mode":"slow"}`, want: []string{"mode: must be one of the enumerated values"}},
+		{name: "array items", doc: `{"name":"orders","limit":1,"tags":["a",2,"c"]}`, want: []string{"tags: must contain at most 2 items", "tags[1]: expected string, got number"}},
+		{name: "additional property", doc: `{"name":"orders","limit":1,"extra":true}`, want: []string{"extra: is not allowed"}},
+		{name: "root type", doc: `[]`, want: []string{"(root): expected object, got array"}},
+		{name: "multiple problems", doc: `{"limit":0}`, want: []string{"name: is required", "limit: must be >= 1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSchema([]byte(schema), []byte(tt.doc))
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			for _, problem := range tt.want {
+				if !strings.Contains(err.Error(), problem) {
+					t.Fatalf("expected %q within %q", problem, err.Error())
+				}
+			}
+		})
+	}
+}
+
+func TestValidateSchemaInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		doc    string
+	}{
+		{name: "invalid schema", schema: `{"type":`, doc: `{}`},
+		{name: "invalid document", schema: `{}`, doc: `{`},
+		{name: "invalid pattern", schema: `{"pattern":"("}`, doc: `"a"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateSchema([]byte(tt.schema), []byte(tt.doc)); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
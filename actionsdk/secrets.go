@@ -0,0 +1,372 @@
+package actionsdk
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultSecretsDir is the default directory containing secrets mounted as files.
+	DefaultSecretsDir = "/run/secrets"
+)
+
+var (
+	// ErrSecretNotFound is returned when a secret isn't present.
+	ErrSecretNotFound = errors.New("secret not found")
+)
+
+var (
+	// secretsDir is the directory checked for secrets mounted as files.
+	secretsDir = DefaultSecretsDir
+
+	// secretProvider resolves secrets prior to checking the secrets directory.
+	secretProvider SecretProvider = envSecretProvider{}
+
+	// secretEnvPrefix restricts the environment variables listed by ListSecrets.
+	secretEnvPrefix string
+
+	// resolved records the values of every secret read by the process, so that they
+	// can be redacted via RedactSecrets.
+	resolved   = map[string]struct{}{}
+	resolvedMu sync.Mutex
+
+	// secretCache caches secrets resolved by custom providers, keyed by name.
+	secretCache    = map[string]cachedSecret{}
+	secretCacheTTL time.Duration
+	secretCacheMu  sync.Mutex
+)
+
+// cachedSecret is a secret resolved by the SecretProvider, along with when the secret
+// was resolved.
+type cachedSecret struct {
+	value string
+	at    time.Time
+}
+
+// redacted replaces secret values within redacted text.
+const redacted = "***"
+
+// SecretProvider resolves secrets by name, allowing secrets to be read from stores
+// such as Vault or AWS Secrets Manager instead of environment variables.
+type SecretProvider interface {
+	// Get returns the secret with the given name and whether the secret exists.
+	Get(name string) (string, bool)
+}
+
+// SetSecretProvider sets the provider used to resolve secrets.  By default secrets are
+// read from environment variables.  Passing nil restores the default provider.  Secrets
+// resolved by custom providers are cached (see SetSecretCacheTTL);  setting the provider
+// clears the cache.
+//
+// This is not safe to call concurrently with reading secrets.
+func SetSecretProvider(p SecretProvider) {
+	if p == nil {
+		p = envSecretProvider{}
+	}
+	secretProvider = p
+	ClearSecretCache()
+}
+
+// SetSecretCacheTTL sets how long secrets resolved by custom providers are cached for,
+// avoiding repeated round-trips to remote stores when reading the same secret.  A zero
+// duration, the default, caches secrets for the lifetime of the process;  a negative
+// duration disables caching.  Secrets read from environment variables are never cached.
+func SetSecretCacheTTL(d time.Duration) {
+	secretCacheMu.Lock()
+	secretCacheTTL = d
+	secretCacheMu.Unlock()
+}
+
+// ClearSecretCache discards every cached secret, such that secrets are resolved by the
+// SecretProvider when next read.
+func ClearSecretCache() {
+	secretCacheMu.Lock()
+	secretCache = map[string]cachedSecret{}
+	secretCacheMu.Unlock()
+}
+
+// providerSecret returns the secret resolved by the SecretProvider and whether the
+// secret exists, reading from the cache where possible.  Only secrets which exist are
+// cached.
+func providerSecret(name string) (string, bool) {
+	p := secretProvider
+	if _, ok := p.(envSecretProvider); ok {
+		return p.Get(name)
+	}
+
+	secretCacheMu.Lock()
+	ttl := secretCacheTTL
+	cached, ok := secretCache[name]
+	secretCacheMu.Unlock()
+	if ok && ttl >= 0 && (ttl == 0 || time.Since(cached.at) < ttl) {
+		return cached.value, true
+	}
+
+	secret, ok := p.Get(name)
+	if ok && ttl >= 0 {
+		secretCacheMu.Lock()
+		secretCache[name] = cachedSecret{value: secret, at: time.Now()}
+		secretCacheMu.Unlock()
+	}
+	return secret, ok
+}
+
+// SecretLister may be implemented by a SecretProvider to list the names of the secrets
+// it can resolve, for use within ListSecrets.
+type SecretLister interface {
+	// List returns the name of every secret the provider can resolve.
+	List() []string
+}
+
+// envSecretProvider is the default SecretProvider, reading secrets from environment
+// variables.
+type envSecretProvider struct{}
+
+func (envSecretProvider) Get(name string) (string, bool) {
+	return os.LookupEnv(name)
+}
+
+// List returns the names of environment variables with the prefix set via
+// SetSecretEnvPrefix.
+func (envSecretProvider) List() []string {
+	names := []string{}
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		if name != "" && strings.HasPrefix(name, secretEnvPrefix) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// SetSecretEnvPrefix restricts the environment variables listed by ListSecrets to those
+// with the given prefix, eg. "SECRET_".  This doesn't affect reading secrets.  An empty
+// prefix, the default, lists every environment variable.
+//
+// This is not safe to call concurrently with listing secrets.
+func SetSecretEnvPrefix(prefix string) {
+	secretEnvPrefix = prefix
+}
+
+// ListSecrets returns the sorted names of the secrets the process can read, without
+// reading their values.  This includes the secrets listed by the SecretProvider, if it
+// implements SecretLister, and files within the secrets directory.  This allows actions
+// to check that required secrets are present on startup:
+//
+//	have := map[string]bool{}
+//	for _, name := range actionsdk.ListSecrets() {
+//		have[name] = true
+//	}
+func ListSecrets() []string {
+	seen := map[string]struct{}{}
+	if l, ok := secretProvider.(SecretLister); ok {
+		for _, name := range l.List() {
+			seen[name] = struct{}{}
+		}
+	}
+	if secretsDir != "" {
+		entries, _ := os.ReadDir(secretsDir)
+		for _, e := range entries {
+			if !e.IsDir() && !strings.HasPrefix(e.Name(), ".") {
+				seen[e.Name()] = struct{}{}
+			}
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetSecretsDir sets the directory checked for secrets mounted as files, such as
+// Docker or Kubernetes secrets.  An empty path disables reading secrets from files.
+//
+// This is not safe to call concurrently with reading secrets.
+func SetSecretsDir(path string) {
+	secretsDir = path
+}
+
+// GetSecret returns the secret stored within the current workspace.  Secrets are read
+// from the SecretProvider, which defaults to environment variables, falling back to a
+// file of the same name within the secrets directory (see SetSecretsDir).  If no secret
+// is found this returns an error.
+func GetSecret(str string) (string, error) {
+	secret, _ := providerSecret(str)
+	if secret == "" {
+		var err error
+		if secret, _, err = readSecretFile(str); err != nil {
+			return "", err
+		}
+	}
+	if secret == "" {
+		return "", fmt.Errorf("%w: %s", ErrSecretNotFound, str)
+	}
+
+	recordSecret(secret)
+	return secret, nil
+}
+
+// LookupSecret returns the secret stored within the current workspace and whether the
+// secret is present, in the same manner as os.LookupEnv.  Unlike GetSecret, a secret
+// which is present but empty returns an empty string and true, allowing optional
+// secrets to be intentionally empty.  The secrets directory is checked if the
+// SecretProvider doesn't contain the secret.
+func LookupSecret(name string) (string, bool) {
+	secret, ok := providerSecret(name)
+	if !ok {
+		var err error
+		if secret, ok, err = readSecretFile(name); err != nil {
+			logger.Warn("unable to read secret", "name", name, "error", err)
+			return "", false
+		}
+	}
+	if ok {
+		recordSecret(secret)
+	}
+	return secret, ok
+}
+
+// recordSecret records the secret's value such that it's redacted via RedactSecrets.
+func recordSecret(secret string) {
+	if secret == "" {
+		return
+	}
+	resolvedMu.Lock()
+	resolved[secret] = struct{}{}
+	resolvedMu.Unlock()
+}
+
+// readSecretFile returns the trimmed contents of the secret file with the given name,
+// and whether the file exists.
+func readSecretFile(name string) (string, bool, error) {
+	// Names must refer to a file directly within the secrets directory.
+	if secretsDir == "" || name == "" || filepath.Base(name) != name {
+		return "", false, nil
+	}
+	byt, err := os.ReadFile(filepath.Join(secretsDir, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("unable to read secret %s: %w", name, err)
+	}
+	return strings.TrimSpace(string(byt)), true, nil
+}
+
+// GetSecretBytes returns the secret stored within the current workspace as bytes.  If no
+// secret is found this returns an error.
+func GetSecretBytes(name string) ([]byte, error) {
+	secret, err := GetSecret(name)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(secret), nil
+}
+
+// GetSecretBase64 returns the base64 decoded value of the secret stored within the current
+// workspace.  If no secret is found or the secret isn't valid base64 this returns an error.
+func GetSecretBase64(name string) ([]byte, error) {
+	secret, err := GetSecret(name)
+	if err != nil {
+		return nil, err
+	}
+	byt, err := base64.StdEncoding.DecodeString(secret)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode secret %s: %w", name, err)
+	}
+	return byt, nil
+}
+
+// GetSecretDefault returns the secret stored within the current workspace, or the given
+// fallback if no secret is found.
+func GetSecretDefault(name, fallback string) string {
+	if secret, err := GetSecret(name); err == nil {
+		return secret
+	}
+	return fallback
+}
+
+// MustGetSecret returns the secret stored within the current workspace, panicking if no
+// secret is found.  This should only be used for secrets which are required for the
+// action to run.
+func MustGetSecret(name string) string {
+	secret, err := GetSecret(name)
+	if err != nil {
+		panic(err)
+	}
+	return secret
+}
+
+// GetSecrets returns each of the given secrets stored within the current workspace, keyed
+// by name.  If any secrets aren't found this returns an error listing every missing secret,
+// alongside the secrets that were found.
+func GetSecrets(names ...string) (map[string]string, error) {
+	secrets := make(map[string]string, len(names))
+	missing := []string{}
+	for _, name := range names {
+		secret, err := GetSecret(name)
+		if err != nil {
+			missing = append(missing, name)
+			continue
+		}
+		secrets[name] = secret
+	}
+	if len(missing) > 0 {
+		return secrets, fmt.Errorf("%w: %s", ErrSecretNotFound, strings.Join(missing, ", "))
+	}
+	return secrets, nil
+}
+
+// GetSecretJSON decodes the JSON-encoded secret stored within the current workspace into
+// dest.  If no secret is found or the secret isn't valid JSON this returns an error.
+func GetSecretJSON(name string, dest interface{}) error {
+	secret, err := GetSecret(name)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(secret), dest); err != nil {
+		return fmt.Errorf("unable to decode secret %s: %w", name, err)
+	}
+	return nil
+}
+
+// Redact masks the given value, returning "***" for any non-empty string.
+func Redact(s string) string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+// RedactSecrets masks the value of every secret read by the process within the given
+// text, such that log lines can be emitted without leaking secrets.
+func RedactSecrets(text string) string {
+	resolvedMu.Lock()
+	secrets := make([]string, 0, len(resolved))
+	for secret := range resolved {
+		secrets = append(secrets, secret)
+	}
+	resolvedMu.Unlock()
+
+	// Replace longer secrets first, so that secrets containing other secrets are
+	// masked entirely.
+	sort.Slice(secrets, func(i, j int) bool {
+		return len(secrets[i]) > len(secrets[j])
+	})
+	for _, secret := range secrets {
+		text = strings.ReplaceAll(text, secret, redacted)
+	}
+	return text
+}
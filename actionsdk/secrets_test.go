@@ -0,0 +1,120 @@
+package actionsdk
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// mapProvider is a SecretProvider backed by a map, recording each lookup.
+type mapProvider struct {
+	secrets map[string]string
+	calls   map[string]int
+}
+
+func (m *mapProvider) Get(name string) (string, bool) {
+	if m.calls == nil {
+		m.calls = map[string]int{}
+	}
+	m.calls[name]++
+	s, ok := m.secrets[name]
+	return s, ok
+}
+
+// useProvider sets the secret provider for the duration of the test, with an empty
+// secrets directory.
+func useProvider(t *testing.T, secrets map[string]string) *mapProvider {
+	t.Helper()
+	p := &mapProvider{secrets: secrets}
+	SetSecretProvider(p)
+	SetSecretsDir(t.TempDir())
+	t.Cleanup(func() {
+		SetSecretProvider(nil)
+		SetSecretsDir(DefaultSecretsDir)
+	})
+	return p
+}
+
+func TestSecretProvider(t *testing.T) {
+	useProvider(t, map[string]string{"API_KEY": "k_123", "EMPTY": ""})
+
+	tests := []struct {
+		name    string
+		want    string
+		wantOK  bool
+		wantErr error
+	}{
+		{name: "API_KEY", want: "k_123", wantOK: true},
+		{name: "EMPTY", want: "", wantOK: true, wantErr: ErrSecretNotFound},
+		{name: "MISSING", want: "", wantOK: false, wantErr: ErrSecretNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetSecret(tt.name)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+			got, ok := LookupSecret(tt.name)
+			if got != tt.want || ok != tt.wantOK {
+				t.Fatalf("expected %q, %v from LookupSecret, got %q, %v", tt.want, tt.wantOK, got, ok)
+			}
+		})
+	}
+}
+
+func TestSecretCache(t *testing.T) {
+	defer SetSecretCacheTTL(0)
+
+	tests := []struct {
+		name      string
+		ttl       time.Duration
+		wait      time.Duration
+		wantCalls int
+	}{
+		{name: "cached for the process", ttl: 0, wantCalls: 1},
+		{name: "cached within ttl", ttl: time.Hour, wantCalls: 1},
+		{name: "expired", ttl: time.Millisecond, wait: 5 * time.Millisecond, wantCalls: 2},
+		{name: "disabled", ttl: -1, wantCalls: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := useProvider(t, map[string]string{"API_KEY": "k_123"})
+			SetSecretCacheTTL(tt.ttl)
+
+			for n := 0; n < 2; n++ {
+				if s, err := GetSecret("API_KEY"); err != nil || s != "k_123" {
+					t.Fatalf("unexpected secret %q, %v", s, err)
+				}
+				time.Sleep(tt.wait)
+			}
+			if p.calls["API_KEY"] != tt.wantCalls {
+				t.Fatalf("expected %d lookups, got %d", tt.wantCalls, p.calls["API_KEY"])
+			}
+		})
+	}
+}
+
+func TestSecretCacheMisses(t *testing.T) {
+	p := useProvider(t, map[string]string{})
+
+	// Missing secrets aren't cached, so that they're found once added.
+	if _, ok := LookupSecret("API_KEY"); ok {
+		t.Fatal("expected the secret to be missing")
+	}
+	p.secrets["API_KEY"] = "k_123"
+	if s, ok := LookupSecret("API_KEY"); !ok || s != "k_123" {
+		t.Fatalf("unexpected secret %q, %v", s, ok)
+	}
+
+	p.secrets["API_KEY"] = "k_456"
+	if s, _ := LookupSecret("API_KEY"); s != "k_123" {
+		t.Fatalf("expected the cached secret, got %q", s)
+	}
+	ClearSecretCache()
+	if s, _ := LookupSecret("API_KEY"); s != "k_456" {
+		t.Fatalf("expected the updated secret once the cache is cleared, got %q", s)
+	}
+}
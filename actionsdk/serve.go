@@ -0,0 +1,106 @@
+package actionsdk
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Serve starts an HTTP server on the given address which runs the handler for each
+// step, allowing actions to run as long-lived services instead of one-shot processes.
+// The engine POSTs the args for each step as the request body, and the handler's result
+// or error is written as the response body in the same format as WriteResult and
+// WriteError.  The response's status code is the result's Status.  Request bodies are
+// limited to the size set via SetMaxArgsSize.
+//
+// This blocks until the server fails, returning the error from http.ListenAndServe.
+func Serve(addr string, fn Handler) error {
+	return http.ListenAndServe(addr, HTTPHandler(fn))
+}
+
+// HTTPHandler returns an http.Handler which runs the handler for each step in the same
+// manner as Serve, allowing actions to be mounted within an existing server.
+//
+// Requests are handled concurrently, and each request's args are never stored within
+// the package-level args used by GetArgs, GetConfig and similar functions.  Handlers
+// must read the request's args from their context via ArgsFromContext, using methods
+// such as (*Args).GetConfig and StepOutputFrom, and emit events via (*Args).EmitEvent
+// for them to be included within the response.  The context is cancelled once the
+// step's deadline passes, with timeouts relative to the request starting.
+func HTTPHandler(fn Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		body := r.Body
+		if maxArgsSize > 0 {
+			body = http.MaxBytesReader(w, r.Body, int64(maxArgsSize))
+		}
+		a, err := GetArgsFromReader(body)
+		if err != nil {
+			var merr *http.MaxBytesError
+			if errors.Is(err, ErrArgsTooLarge) || errors.As(err, &merr) {
+				w.WriteHeader(http.StatusRequestEntityTooLarge)
+			} else {
+				w.WriteHeader(http.StatusBadRequest)
+			}
+			_ = WriteErrorTo(w, err, false)
+			return
+		}
+
+		evt, err := a.GetEvent()
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = WriteErrorTo(w, err, false)
+			return
+		}
+		ctx := WithArgs(traceContext(r.Context(), a), a)
+		if deadline, ok := a.deadline(start); ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithDeadline(ctx, deadline)
+			defer cancel()
+		}
+
+		out, err := invoke(ctx, fn, evt)
+		if err != nil {
+			// As with Run, errors returned by the handler are retryable by default.
+			if isRetryable(err, true) {
+				w.WriteHeader(http.StatusInternalServerError)
+			} else {
+				w.WriteHeader(http.StatusBadRequest)
+			}
+			_ = WriteErrorTo(w, err, true)
+			return
+		}
+		res := toResult(out)
+		buf := &bytes.Buffer{}
+		if _, err := writeResult(buf, res, encode, resultExtra{Events: a.takeEmittedEvents()}); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = WriteErrorTo(w, err, false)
+			return
+		}
+		w.WriteHeader(resultStatus(res))
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			logger.Error("unable to write result", "error", err)
+		}
+	})
+}
+
+// resultStatus returns the HTTP status code for the result, which is the result's Status
+// if valid.  Nil results are written with a 201 status, as with WriteResult.
+func resultStatus(res *Result) int {
+	switch {
+	case res == nil:
+		return http.StatusCreated
+	case res.Status >= 200 && res.Status <= 599:
+		return res.Status
+	}
+	return http.StatusOK
+}
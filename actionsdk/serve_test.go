@@ -0,0 +1,150 @@
+package actionsdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// serve sends the body to the handler, returning the response's status code and body.
+func serve(t *testing.T, h http.Handler, method, body string) (int, map[string]interface{}) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(method, "/", strings.NewReader(body)))
+	resp := map[string]interface{}{}
+	if w.Code != http.StatusMethodNotAllowed {
+		if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+			t.Fatalf("expected a json response, got %q", ct)
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("invalid response %q: %v", w.Body.String(), err)
+		}
+	}
+	return w.Code, resp
+}
+
+func TestHTTPHandler(t *testing.T) {
+	SetMaxArgsSize(1024)
+	defer SetMaxArgsSize(DefaultMaxArgsSize)
+
+	h := HTTPHandler(func(ctx context.Context, e Event) (interface{}, error) {
+		switch e.Name {
+		case "accepted":
+			return &Result{Body: "queued", Status: 202}, nil
+		case "nil":
+			return nil, nil
+		case "retry":
+			return nil, errors.New("upstream unavailable")
+		case "fail":
+			return nil, NonRetryable(errors.New("invalid order"))
+		case "panic":
+			panic("boom")
+		}
+		return map[string]string{"event": e.Name}, nil
+	})
+
+	tests := []struct {
+		name       string
+		method     string
+		body       string
+		wantStatus int
+		wantErr    bool
+	}{
+		{name: "result", body: `{"event":{"name":"order/placed"}}`, wantStatus: 200},
+		{name: "result status", body: `{"event":{"name":"accepted"}}`, wantStatus: 202},
+		{name: "nil result", body: `{"event":{"name":"nil"}}`, wantStatus: 200},
+		{name: "retryable error", body: `{"event":{"name":"retry"}}`, wantStatus: 500, wantErr: true},
+		{name: "non-retryable error", body: `{"event":{"name":"fail"}}`, wantStatus: 400, wantErr: true},
+		{name: "panic", body: `{"event":{"name":"panic"}}`, wantStatus: 500, wantErr: true},
+		{name: "invalid args", body: `{"event":`, wantStatus: 400, wantErr: true},
+		{name: "no event", body: `{}`, wantStatus: 400, wantErr: true},
+		{name: "body too large", body: `{"event":{"name":"` + strings.Repeat("x", 2048) + `"}}`, wantStatus: 413, wantErr: true},
+		{name: "decompressed too large", body: string(gzipBytes(t, largeStepsPayload(t, 5))), wantStatus: 413, wantErr: true},
+		{name: "method not allowed", method: http.MethodGet, wantStatus: 405},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodPost
+			}
+			status, resp := serve(t, h, method, tt.body)
+			if status != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %v", tt.wantStatus, status, resp)
+			}
+			if _, ok := resp["error"]; ok != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, resp)
+			}
+		})
+	}
+}
+
+func TestHTTPHandlerConcurrent(t *testing.T) {
+	ResetArgs()
+	defer ResetArgs()
+
+	h := HTTPHandler(func(ctx context.Context, e Event) (interface{}, error) {
+		a, ok := ArgsFromContext(ctx)
+		if !ok {
+			return nil, errors.New("no args within context")
+		}
+		id, _, err := a.GetConfigString("id")
+		if err != nil {
+			return nil, err
+		}
+		// Emit more than one event, so that interleaved requests would be observed.
+		for n := 0; n < 2; n++ {
+			a.EmitEvent(Event{Name: "order/shipped", Data: map[string]interface{}{"id": id}})
+		}
+		return map[string]string{"id": id}, nil
+	})
+
+	const n = 32
+	wg := sync.WaitGroup{}
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			id := fmt.Sprintf("o_%d", i)
+			w := httptest.NewRecorder()
+			body := fmt.Sprintf(`{"event":{"name":"order/placed"},"config":{"id":%q}}`, id)
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+
+			resp := struct {
+				Body   map[string]string `json:"body"`
+				Events []Event           `json:"events"`
+			}{}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				errs <- fmt.Errorf("invalid response %q: %w", w.Body.String(), err)
+				return
+			}
+			if resp.Body["id"] != id || len(resp.Events) != 2 {
+				errs <- fmt.Errorf("unexpected response for %s: %s", id, w.Body.String())
+				return
+			}
+			for _, evt := range resp.Events {
+				if evt.Data["id"] != id {
+					errs <- fmt.Errorf("unexpected event for %s: %v", id, evt)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	argsMu.Lock()
+	defer argsMu.Unlock()
+	if args != nil {
+		t.Fatal("expected requests not to set the package-level args")
+	}
+}
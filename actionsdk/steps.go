@@ -0,0 +1,310 @@
+package actionsdk
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	// stepsMu guards lazily decoding Steps.
+	stepsMu sync.Mutex
+)
+
+var (
+	// ErrStepNotFound is returned when the output of a previous step isn't present.
+	ErrStepNotFound = errors.New("step not found")
+
+	// ErrStepOutputKeyNotFound is returned when a key isn't present within the output
+	// of a previous step.
+	ErrStepOutputKeyNotFound = errors.New("key not found in step output")
+)
+
+// StepOutput decodes the output of the previous step with the given ID into the given
+// type:
+//
+//	resp, err := actionsdk.StepOutput[HTTPResponse]("fetch-user")
+//
+// This returns an error if the step's output isn't present, or if the output can't be
+// decoded into T.
+func StepOutput[T any](id string) (T, error) {
+	args, err := GetArgs()
+	if err != nil {
+		var dest T
+		return dest, err
+	}
+	return StepOutputFrom[T](args, id)
+}
+
+// StepOutputFrom decodes the output of the previous step with the given ID within the
+// given args into the given type, in the same manner as StepOutput.  This allows typed
+// access to step output within handlers run via Serve:
+//
+//	args, _ := actionsdk.ArgsFromContext(ctx)
+//	resp, err := actionsdk.StepOutputFrom[HTTPResponse](args, "fetch-user")
+func StepOutputFrom[T any](a *Args, id string) (T, error) {
+	var dest T
+	err := a.GetStepOutput(id, &dest)
+	return dest, err
+}
+
+// GetStepOutput decodes the output of the previous step with the given ID into dest.
+// This returns ErrStepNotFound if the step's output isn't present.
+func (a *Args) GetStepOutput(id string, dest interface{}) error {
+	output, err := a.stepOutput(id)
+	if err != nil {
+		return err
+	}
+	if err := remarshal(output, dest); err != nil {
+		return fmt.Errorf("error decoding output of step %s: %w", id, err)
+	}
+	return nil
+}
+
+// GetStepOutputInto decodes a single key from the output of the previous step with the
+// given ID into dest.  This returns ErrStepNotFound if the step's output isn't present,
+// or ErrStepOutputKeyNotFound if the key isn't present within the step's output.
+func GetStepOutputInto(id, key string, dest interface{}) error {
+	args, err := GetArgs()
+	if err != nil {
+		return err
+	}
+	return args.GetStepOutputInto(id, key, dest)
+}
+
+// GetStepOutputInto decodes a single key from the output of the previous step with the
+// given ID into dest in the same manner as the package-level GetStepOutputInto.
+func (a *Args) GetStepOutputInto(id, key string, dest interface{}) error {
+	output, err := a.stepOutput(id)
+	if err != nil {
+		return err
+	}
+	val, ok := output[key]
+	if !ok {
+		return fmt.Errorf("%w: step %s has no key %s", ErrStepOutputKeyNotFound, id, key)
+	}
+	if err := remarshal(val, dest); err != nil {
+		return fmt.Errorf("error decoding %s from output of step %s: %w", key, id, err)
+	}
+	return nil
+}
+
+// GetStepOutputRaw returns the output of the previous step with the given ID as JSON,
+// allowing the output to be forwarded verbatim or decoded lazily.  This returns
+// ErrStepNotFound if the step's output isn't present, or an empty json.RawMessage if
+// the step's output is present but empty.
+func GetStepOutputRaw(id string) (json.RawMessage, error) {
+	args, err := GetArgs()
+	if err != nil {
+		return nil, err
+	}
+	return args.GetStepOutputRaw(id)
+}
+
+// GetStepOutputRaw returns the output of the previous step with the given ID as JSON in
+// the same manner as the package-level GetStepOutputRaw.
+func (a *Args) GetStepOutputRaw(id string) (json.RawMessage, error) {
+	raw, err := a.stepOutputRaw(id)
+	if err != nil {
+		return nil, err
+	}
+	if isEmptyJSON(raw) || bytes.Equal(bytes.TrimSpace(raw), []byte("{}")) {
+		return json.RawMessage{}, nil
+	}
+	return raw, nil
+}
+
+// GetStepOutputByName decodes the output of the previous step with the given name into
+// dest.  Unlike step IDs, names remain stable as steps are added to or reordered within
+// the function.  This returns ErrStepNotFound if no step has the given name or if the
+// step's output isn't present.
+func GetStepOutputByName(name string, dest interface{}) error {
+	args, err := GetArgs()
+	if err != nil {
+		return err
+	}
+	return args.GetStepOutputByName(name, dest)
+}
+
+// GetStepOutputByName decodes the output of the previous step with the given name into
+// dest in the same manner as the package-level GetStepOutputByName.
+func (a *Args) GetStepOutputByName(name string, dest interface{}) error {
+	var names map[string]string
+	if a != nil {
+		names = a.StepNames
+	}
+	ids := []string{}
+	for id, n := range names {
+		if n == name {
+			ids = append(ids, id)
+		}
+	}
+	switch len(ids) {
+	case 0:
+		return fmt.Errorf("%w: no step named %s", ErrStepNotFound, name)
+	case 1:
+	default:
+		sort.Strings(ids)
+		return fmt.Errorf("multiple steps named %s: %s", name, strings.Join(ids, ", "))
+	}
+
+	output, err := a.stepOutput(ids[0])
+	if err != nil {
+		return err
+	}
+	if err := remarshal(output, dest); err != nil {
+		return fmt.Errorf("error decoding output of step %s: %w", name, err)
+	}
+	return nil
+}
+
+// ListStepIDs returns the sorted IDs of every previous step whose output is present.
+func ListStepIDs() ([]string, error) {
+	args, err := GetArgs()
+	if err != nil {
+		return nil, err
+	}
+	return args.ListStepIDs()
+}
+
+// ListStepIDs returns the sorted IDs of every previous step whose output is present in
+// the same manner as the package-level ListStepIDs.
+func (a *Args) ListStepIDs() ([]string, error) {
+	steps, err := a.steps()
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(steps))
+	for id := range steps {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// HasStep returns whether the output of the previous step with the given ID is present.
+func HasStep(id string) (bool, error) {
+	args, err := GetArgs()
+	if err != nil {
+		return false, err
+	}
+	return args.HasStep(id)
+}
+
+// HasStep returns whether the output of the previous step with the given ID is present.
+func (a *Args) HasStep(id string) (bool, error) {
+	steps, err := a.steps()
+	if err != nil {
+		return false, err
+	}
+	_, ok := steps[id]
+	return ok, nil
+}
+
+// AllStepOutputs returns the output of every previous step, keyed by step ID.  The
+// returned map is a copy and is never nil, so it may be modified without affecting the
+// args used by other accessors.
+func AllStepOutputs() (map[string]map[string]interface{}, error) {
+	args, err := GetArgs()
+	if err != nil {
+		return nil, err
+	}
+	return args.AllStepOutputs()
+}
+
+// AllStepOutputs returns a copy of the output of every previous step in the same manner
+// as the package-level AllStepOutputs.
+func (a *Args) AllStepOutputs() (map[string]map[string]interface{}, error) {
+	steps, err := a.steps()
+	if err != nil {
+		return nil, err
+	}
+	outputs := make(map[string]map[string]interface{}, len(steps))
+	for id, output := range steps {
+		copied := make(map[string]interface{}, len(output))
+		for k, v := range output {
+			copied[k] = v
+		}
+		outputs[id] = copied
+	}
+	return outputs, nil
+}
+
+// stepOutput returns the output of the previous step with the given ID.
+func (a *Args) stepOutput(id string) (map[string]interface{}, error) {
+	steps, err := a.steps()
+	if err != nil {
+		return nil, err
+	}
+	output, ok := steps[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrStepNotFound, id)
+	}
+	return output, nil
+}
+
+// stepOutputRaw returns the JSON-encoded output of the previous step with the given ID.
+// If Steps is yet to be decoded the output is returned as sent, without decoding Steps.
+func (a *Args) stepOutputRaw(id string) (json.RawMessage, error) {
+	if a == nil {
+		return nil, fmt.Errorf("%w: %s", ErrStepNotFound, id)
+	}
+
+	stepsMu.Lock()
+	rawSteps := a.rawSteps
+	stepsMu.Unlock()
+	if rawSteps == nil {
+		steps, err := a.steps()
+		if err != nil {
+			return nil, err
+		}
+		output, ok := steps[id]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrStepNotFound, id)
+		}
+		return json.Marshal(output)
+	}
+
+	outputs := map[string]json.RawMessage{}
+	if err := json.Unmarshal(rawSteps, &outputs); err != nil {
+		return nil, fmt.Errorf("unable to parse step output: %w", err)
+	}
+	raw, ok := outputs[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrStepNotFound, id)
+	}
+	return raw, nil
+}
+
+// DecodeSteps decodes the output of previous steps into the Steps field, if not already
+// decoded.  This only needs to be called prior to reading the Steps field directly when
+// lazy decoding is enabled via SetLazySteps.
+func (a *Args) DecodeSteps() error {
+	_, err := a.steps()
+	return err
+}
+
+// steps returns the output of previous steps, decoding them on first use.  Nil args
+// contain no steps.
+func (a *Args) steps() (map[string]map[string]interface{}, error) {
+	if a == nil {
+		return nil, nil
+	}
+
+	stepsMu.Lock()
+	defer stepsMu.Unlock()
+
+	if a.rawSteps != nil {
+		steps := map[string]map[string]interface{}{}
+		if err := decodeJSON(a.rawSteps, &steps); err != nil {
+			return nil, fmt.Errorf("unable to parse step output: %w", err)
+		}
+		a.Steps = steps
+		a.rawSteps = nil
+	}
+	return a.Steps, nil
+}
@@ -0,0 +1,108 @@
+package actionsdk
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+const (
+	// TraceParentKey is the key within Args.Ctx containing the W3C traceparent
+	// propagated from the function's originating trace.
+	TraceParentKey = "traceparent"
+
+	// TraceStateKey is the key within Args.Ctx containing the W3C tracestate
+	// propagated alongside the traceparent.
+	TraceStateKey = "tracestate"
+)
+
+// SpanContext is the W3C trace context propagated to the step, identifying the span
+// which the step's spans should attach to.  This may be converted to a tracing
+// library's span context, eg. via OpenTelemetry's trace.NewSpanContext.
+type SpanContext struct {
+	TraceID    [16]byte
+	SpanID     [8]byte
+	TraceFlags byte
+	TraceState string
+}
+
+// Sampled returns whether the sampled flag is set within the trace flags.
+func (s SpanContext) Sampled() bool {
+	return s.TraceFlags&0x01 == 0x01
+}
+
+type spanContextKey struct{}
+
+// TraceContext returns a context containing the SpanContext propagated within the args,
+// which can be retrieved via SpanContextFromContext.  If no trace context is present
+// this returns context.Background() with no error.
+//
+// As per the W3C Trace Context specification an invalid traceparent is ignored, such
+// that the step starts a new trace;  the invalid value is logged via the logger set with
+// SetLogger.
+func TraceContext() (context.Context, error) {
+	args, err := GetArgs()
+	if err != nil {
+		return nil, err
+	}
+	return traceContext(context.Background(), args), nil
+}
+
+// traceContext returns a copy of ctx containing the SpanContext propagated within the
+// given args.  Invalid trace context is logged and ignored.
+func traceContext(ctx context.Context, a *Args) context.Context {
+	if a == nil {
+		return ctx
+	}
+	parent, _ := a.Ctx[TraceParentKey].(string)
+	if parent == "" {
+		return ctx
+	}
+	sc, err := parseTraceParent(parent)
+	if err != nil {
+		logger.Warn("ignoring invalid trace context", "error", err)
+		return ctx
+	}
+	sc.TraceState, _ = a.Ctx[TraceStateKey].(string)
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
+
+// SpanContextFromContext returns the SpanContext stored within the given context by
+// TraceContext.
+func SpanContextFromContext(ctx context.Context) (SpanContext, bool) {
+	sc, ok := ctx.Value(spanContextKey{}).(SpanContext)
+	return sc, ok
+}
+
+// parseTraceParent parses a W3C traceparent header:  "00-<trace id>-<span id>-<flags>".
+func parseTraceParent(s string) (SpanContext, error) {
+	sc := SpanContext{}
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	// Future versions may append fields, but must keep the existing fields.
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return sc, fmt.Errorf("invalid traceparent: %s", s)
+	}
+
+	var flags [1]byte
+	for _, f := range []struct {
+		dest []byte
+		src  string
+	}{
+		{sc.TraceID[:], parts[1]},
+		{sc.SpanID[:], parts[2]},
+		{flags[:], parts[3]},
+	} {
+		if hex.DecodedLen(len(f.src)) != len(f.dest) || strings.ToLower(f.src) != f.src {
+			return sc, fmt.Errorf("invalid traceparent: %s", s)
+		}
+		if _, err := hex.Decode(f.dest, []byte(f.src)); err != nil {
+			return sc, fmt.Errorf("invalid traceparent: %s", s)
+		}
+	}
+	if sc.TraceID == [16]byte{} || sc.SpanID == [8]byte{} {
+		return sc, fmt.Errorf("invalid traceparent: %s", s)
+	}
+	sc.TraceFlags = flags[0]
+	return sc, nil
+}
@@ -0,0 +1,46 @@
+package actionsdk
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTraceContext(t *testing.T) {
+	const valid = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	tests := []struct {
+		name        string
+		traceparent interface{}
+		wantOK      bool
+		wantSampled bool
+	}{
+		{name: "valid", traceparent: valid, wantOK: true, wantSampled: true},
+		{name: "unsampled", traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", wantOK: true},
+		{name: "future version with extra fields", traceparent: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-x", wantOK: true, wantSampled: true},
+		{name: "absent", traceparent: nil},
+		{name: "not a string", traceparent: 1},
+		{name: "invalid version", traceparent: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{name: "extra fields", traceparent: valid + "-x"},
+		{name: "upper case", traceparent: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01"},
+		{name: "short trace id", traceparent: "00-4bf92f35-00f067aa0ba902b7-01"},
+		{name: "zero trace id", traceparent: "00-00000000000000000000000000000000-00f067aa0ba902b7-01"},
+		{name: "zero span id", traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01"},
+		{name: "malformed", traceparent: "garbage"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &Args{Ctx: map[string]interface{}{TraceParentKey: tt.traceparent, TraceStateKey: "vendor=1"}}
+			ctx := traceContext(context.Background(), a)
+			sc, ok := SpanContextFromContext(ctx)
+			if ok != tt.wantOK {
+				t.Fatalf("expected span context %v, got %v", tt.wantOK, ok)
+			}
+			if !ok {
+				return
+			}
+			if sc.Sampled() != tt.wantSampled || sc.TraceState != "vendor=1" || sc.SpanID[7] != 0xb7 {
+				t.Fatalf("unexpected span context: %+v", sc)
+			}
+		})
+	}
+}